@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v5.27.1
+// source: auth/keys.proto
+
+package authv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// KeyControllerClient is the client API for KeyController service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KeyControllerClient interface {
+	RotateSigningKey(ctx context.Context, in *RotateSigningKeyRequest, opts ...grpc.CallOption) (*SigningKey, error)
+	ListSigningKeys(ctx context.Context, in *ListSigningKeysRequest, opts ...grpc.CallOption) (*ListSigningKeysResponse, error)
+}
+
+type keyControllerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyControllerClient(cc grpc.ClientConnInterface) KeyControllerClient {
+	return &keyControllerClient{cc}
+}
+
+func (c *keyControllerClient) RotateSigningKey(ctx context.Context, in *RotateSigningKeyRequest, opts ...grpc.CallOption) (*SigningKey, error) {
+	out := new(SigningKey)
+	err := c.cc.Invoke(ctx, "/auth.KeyController/RotateSigningKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyControllerClient) ListSigningKeys(ctx context.Context, in *ListSigningKeysRequest, opts ...grpc.CallOption) (*ListSigningKeysResponse, error) {
+	out := new(ListSigningKeysResponse)
+	err := c.cc.Invoke(ctx, "/auth.KeyController/ListSigningKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeyControllerServer is the server API for KeyController service.
+// All implementations must embed UnimplementedKeyControllerServer
+// for forward compatibility
+type KeyControllerServer interface {
+	RotateSigningKey(context.Context, *RotateSigningKeyRequest) (*SigningKey, error)
+	ListSigningKeys(context.Context, *ListSigningKeysRequest) (*ListSigningKeysResponse, error)
+	mustEmbedUnimplementedKeyControllerServer()
+}
+
+// UnimplementedKeyControllerServer must be embedded to have forward compatible implementations.
+type UnimplementedKeyControllerServer struct {
+}
+
+func (UnimplementedKeyControllerServer) RotateSigningKey(context.Context, *RotateSigningKeyRequest) (*SigningKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateSigningKey not implemented")
+}
+func (UnimplementedKeyControllerServer) ListSigningKeys(context.Context, *ListSigningKeysRequest) (*ListSigningKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSigningKeys not implemented")
+}
+func (UnimplementedKeyControllerServer) mustEmbedUnimplementedKeyControllerServer() {}
+
+// UnsafeKeyControllerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KeyControllerServer will
+// result in compilation errors.
+type UnsafeKeyControllerServer interface {
+	mustEmbedUnimplementedKeyControllerServer()
+}
+
+func RegisterKeyControllerServer(s grpc.ServiceRegistrar, srv KeyControllerServer) {
+	s.RegisterService(&KeyController_ServiceDesc, srv)
+}
+
+func _KeyController_RotateSigningKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateSigningKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyControllerServer).RotateSigningKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.KeyController/RotateSigningKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyControllerServer).RotateSigningKey(ctx, req.(*RotateSigningKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyController_ListSigningKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSigningKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyControllerServer).ListSigningKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.KeyController/ListSigningKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyControllerServer).ListSigningKeys(ctx, req.(*ListSigningKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KeyController_ServiceDesc is the grpc.ServiceDesc for KeyController service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KeyController_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.KeyController",
+	HandlerType: (*KeyControllerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RotateSigningKey",
+			Handler:    _KeyController_RotateSigningKey_Handler,
+		},
+		{
+			MethodName: "ListSigningKeys",
+			Handler:    _KeyController_ListSigningKeys_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "auth/keys.proto",
+}
@@ -13,11 +13,15 @@ func main() {
 	lg := logger.SetupLogger(cfg.Env)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	application := app.New(ctx, lg, cfg.GRPC.Port, cfg.DB, cfg.TokenTTL)
+	application := app.New(ctx, lg, cfg)
 
 	go func() {
 		application.GRPCServer.MustRun()
 	}()
 
+	go func() {
+		application.HTTPServer.MustRun()
+	}()
+
 	application.GracefulStop(cancel)
 }
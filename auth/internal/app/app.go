@@ -2,6 +2,8 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -9,21 +11,41 @@ import (
 	"time"
 
 	"github.com/viacheslavek/grpcauth/auth/internal/app/grpcapp"
+	"github.com/viacheslavek/grpcauth/auth/internal/app/httpapp"
 	"github.com/viacheslavek/grpcauth/auth/internal/config"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/actiontoken"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/breached"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/denylist"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/loginlimit"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/mailer"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/passhash"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/pepper"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/secretbox"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/appCtl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/keyCtl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/orgCtl"
 	"github.com/viacheslavek/grpcauth/auth/internal/services/ownerCtl"
 	"github.com/viacheslavek/grpcauth/auth/internal/storage/postgres"
 )
 
+const (
+	denylistPruneInterval      = 10 * time.Minute
+	loginAttemptsPruneInterval = 10 * time.Minute
+	keyRingRunInterval         = time.Minute
+	httpShutdownTimeout        = 5 * time.Second
+)
+
 type App struct {
 	GRPCServer *grpcapp.App
+	HTTPServer *httpapp.App
 	log        *slog.Logger
 }
 
-func New(
-	ctx context.Context, log *slog.Logger,
-	grpcPort int, database config.StorageConfig, tokenTTL time.Duration,
-) *App {
-	db, errN := postgres.New(ctx, log, database)
+func New(ctx context.Context, log *slog.Logger, cfg *config.Config) *App {
+	db, errN := postgres.New(ctx, log, cfg.DB)
 	if errN != nil {
 		log.Error("failed to init database")
 		panic(errN)
@@ -34,12 +56,75 @@ func New(
 		panic(err)
 	}
 
-	ownerService := ownerCtl.New(log, db, db, tokenTTL)
+	mustSetupPasswordPolicy(log, cfg.PasswordPolicy)
+
+	keys, keyRing := mustSetupKeys(ctx, log, cfg, db)
+	tokens := jwt.NewManager(keys, cfg.JWT.LegacyHS256Until)
+	if !cfg.JWT.LegacyHS256Until.IsZero() {
+		log.Warn("HS256 tokens are accepted next to signing keys",
+			slog.Time("until", cfg.JWT.LegacyHS256Until))
+	}
+
+	revoked := denylist.New(log, db)
+	go revoked.Run(ctx, denylistPruneInterval)
+
+	ownerService := ownerCtl.New(log, db,
+		ownerCtl.Deps{
+			Passwords:    mustSetupPasswordHasher(log, cfg.PasswordHash),
+			Pepper:       mustSetupPepper(log, cfg.PasswordHash),
+			Secrets:      mustSetupSecretBox(log, cfg.MFA),
+			Mailer:       mustSetupMailer(log, cfg.Email),
+			ActionTokens: mustSetupActionTokens(log, cfg.Email),
+			Denylist:     revoked,
+			Tokens:       tokens,
+		},
+		ownerCtl.Config{
+			TokenTTL:             cfg.TokenTTL,
+			RefreshTokenTTL:      cfg.RefreshTokenTTL,
+			MFAIssuer:            cfg.MFA.Issuer,
+			MFAChallengeTTL:      cfg.MFA.ChallengeTTL,
+			RequireVerifiedEmail: cfg.Email.RequireVerified,
+			VerificationTTL:      cfg.Email.VerificationTTL,
+			VerificationURL:      cfg.Email.VerificationURL,
+			PasswordResetTTL:     cfg.Email.ResetTTL,
+			PasswordResetLimit:   cfg.Email.ResetLimit,
+			PasswordResetURL:     cfg.Email.ResetURL,
+			PasswordHistory:      cfg.PasswordPolicy.HistorySize,
+			ListPageSize:         cfg.Listing.DefaultPageSize,
+			MaxListPageSize:      cfg.Listing.MaxPageSize,
+			LoginLimit: loginlimit.Policy{
+				Window:          cfg.LoginLimit.Window,
+				BackoffAfter:    cfg.LoginLimit.BackoffAfter,
+				BaseDelay:       cfg.LoginLimit.BaseDelay,
+				MaxDelay:        cfg.LoginLimit.MaxDelay,
+				LockoutAfter:    cfg.LoginLimit.LockoutAfter,
+				LockoutDuration: cfg.LoginLimit.LockoutDuration,
+			},
+			IPLoginLimit: loginlimit.Policy{
+				Window:       cfg.LoginLimit.Window,
+				BackoffAfter: cfg.LoginLimit.IPBackoffAfter,
+				BaseDelay:    cfg.LoginLimit.BaseDelay,
+				MaxDelay:     cfg.LoginLimit.MaxDelay,
+			},
+		},
+	)
+	go ownerService.PruneLoginAttempts(ctx, loginAttemptsPruneInterval)
+
+	appService := appCtl.New(log, db, db)
 
-	grpcApp := grpcapp.New(log, ownerService, grpcPort)
+	mustBootstrapAdmin(ctx, log, ownerService, appService, cfg.RBAC)
+
+	keyService := keyCtl.New(log, keyRing)
+
+	orgService := orgCtl.New(log, db, db, db, ownerService)
+
+	grpcApp := grpcapp.New(log, ownerService, appService, keyService, orgService, cfg.GRPC.Port)
+
+	httpApp := httpapp.New(log, tokens, cfg.HTTP.Port, cfg.HTTP.Timeout)
 
 	return &App{
 		GRPCServer: grpcApp,
+		HTTPServer: httpApp,
 		log:        log,
 	}
 }
@@ -51,8 +136,230 @@ func (a *App) GracefulStop(cancel context.CancelFunc) {
 
 	a.GRPCServer.Stop()
 
+	ctx, cancelHTTP := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancelHTTP()
+	a.HTTPServer.Stop(ctx)
+
 	a.log.Info("cancel context")
 	cancel()
 
 	a.log.Info("Gracefully stopped")
 }
+
+// mustBootstrapAdmin Makes sure the configured admin owner exists with the admin role
+// and has an app to log in to
+func mustBootstrapAdmin(
+	ctx context.Context, log *slog.Logger, owners *ownerCtl.OwnerCtl, apps *appCtl.AppCtl, cfg config.RBACConfig,
+) {
+	if cfg.AdminLogin == "" {
+		log.Warn("rbac admin is not configured, roles are only assigned by existing admins")
+		return
+	}
+
+	admin := models.Owner{}
+	if err := admin.SetLogin(cfg.AdminLogin); err != nil {
+		panic(err)
+	}
+	if err := admin.SetEmail(cfg.AdminEmail); err != nil {
+		panic(err)
+	}
+	// The password is only needed to create the admin
+	if err := admin.SetPassword(cfg.AdminPassword); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
+		log.Error("rbac admin password does not follow the password policy")
+		panic(err)
+	}
+
+	if err := owners.BootstrapAdmin(ctx, admin); err != nil {
+		log.Error("failed to bootstrap rbac admin")
+		panic(err)
+	}
+
+	app, err := apps.BootstrapApp(ctx, cfg.AdminApp)
+	if err != nil {
+		log.Error("failed to bootstrap rbac admin app")
+		panic(err)
+	}
+
+	log.Info("rbac admin logs in to the app", slog.String("name", app.Name()), slog.Int("id", int(app.Id())))
+}
+
+// mustSetupKeys Returns the key ring only when it is enabled
+func mustSetupKeys(
+	ctx context.Context, log *slog.Logger, cfg *config.Config, store jwt.KeyStore,
+) (jwt.KeySet, keyCtl.KeyRing) {
+	ringCfg := cfg.JWT.KeyRing
+	if !ringCfg.Enabled {
+		return jwt.StaticKeys(mustLoadKeys(log, cfg.JWT)), nil
+	}
+
+	if len(cfg.JWT.Keys) > 0 {
+		log.Warn("key ring is enabled, configured signing keys are ignored")
+	}
+
+	retireAfter := ringCfg.RetireAfter
+	if retireAfter < cfg.TokenTTL {
+		log.Warn("key ring retire_after is shorter than token_ttl, using token_ttl")
+		retireAfter = cfg.TokenTTL
+	}
+
+	box, err := secretbox.NewFromBase64(ringCfg.SecretKey)
+	if err != nil {
+		log.Error("failed to init key ring secret box, secret_key must be set when the key ring is enabled")
+		panic(err)
+	}
+
+	ring, err := jwt.NewKeyRing(log, store, box, ringCfg.Algorithm, retireAfter, ringCfg.RotateEvery)
+	if err != nil {
+		log.Error("failed to init key ring")
+		panic(err)
+	}
+
+	if err = ring.Load(ctx); err != nil {
+		log.Error("failed to load key ring")
+		panic(err)
+	}
+
+	go ring.Run(ctx, keyRingRunInterval)
+
+	return ring, ring
+}
+
+func mustLoadKeys(log *slog.Logger, cfg config.JWTConfig) []jwt.Key {
+	keys := make([]jwt.Key, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		key, err := jwt.LoadKey(keyCfg.Id, keyCfg.Algorithm, keyCfg.Path)
+		if err != nil {
+			log.Error("failed to load signing key", slog.String("kid", keyCfg.Id))
+			panic(err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		log.Info("no signing keys configured, tokens are signed with app secrets")
+	}
+
+	return keys
+}
+
+// mustSetupPasswordPolicy Sets the policy checked wherever a password is validated
+func mustSetupPasswordPolicy(log *slog.Logger, cfg config.PasswordPolicyConfig) {
+	validator.SetPasswordPolicy(validator.PasswordPolicy{
+		MinLength:           cfg.MinLength,
+		MaxLength:           cfg.MaxLength,
+		RequireLetter:       cfg.RequireLetter,
+		RequireLower:        cfg.RequireLower,
+		RequireUpper:        cfg.RequireUpper,
+		RequireDigit:        cfg.RequireDigit,
+		RequireSymbol:       cfg.RequireSymbol,
+		MaxRepeated:         cfg.MaxRepeated,
+		MinEntropy:          cfg.MinEntropy,
+		ForbidPersonal:      cfg.ForbidPersonal,
+		ForbiddenSubstrings: cfg.ForbiddenSubstrings,
+		Breached:            mustSetupBreachedPasswords(log, cfg),
+	})
+
+	log.Info("password policy set", slog.Int("min_length", cfg.MinLength))
+}
+
+// mustSetupBreachedPasswords Returns nil when no list is configured, breached passwords are not checked then
+func mustSetupBreachedPasswords(log *slog.Logger, cfg config.PasswordPolicyConfig) validator.BreachedPasswords {
+	if cfg.BreachedList == "" && cfg.BreachedHIBPDir == "" {
+		log.Warn("breached password list is not configured, breached passwords are not rejected")
+		return nil
+	}
+
+	filter, err := breached.Load(cfg.BreachedList, cfg.BreachedHIBPDir, cfg.BreachedFalsePositive)
+	if err != nil {
+		log.Error("failed to load breached password list")
+		panic(err)
+	}
+
+	log.Info("breached password list loaded")
+
+	return filter
+}
+
+func mustSetupPasswordHasher(log *slog.Logger, cfg config.PasswordHashConfig) *passhash.Hasher {
+	hasher, err := passhash.New(passhash.Params{
+		Algorithm:   cfg.Algorithm,
+		BcryptCost:  cfg.BcryptCost,
+		Memory:      cfg.Memory,
+		Iterations:  cfg.Iterations,
+		Parallelism: cfg.Parallelism,
+	})
+	if err != nil {
+		log.Error("failed to init password hasher")
+		panic(err)
+	}
+
+	return hasher
+}
+
+// mustSetupPepper Passwords are hashed without a pepper when no file is configured
+func mustSetupPepper(log *slog.Logger, cfg config.PasswordHashConfig) *pepper.Peppers {
+	if cfg.PepperFile == "" {
+		log.Warn("password pepper file is not configured, passwords are hashed without a pepper")
+
+		peppers, _ := pepper.New(nil)
+		return peppers
+	}
+
+	peppers, err := pepper.Load(cfg.PepperFile)
+	if err != nil {
+		log.Error("failed to load password pepper")
+		panic(err)
+	}
+
+	log.Info("password pepper loaded", slog.Int("version", peppers.CurrentVersion()))
+
+	return peppers
+}
+
+// mustSetupSecretBox Returns nil when no key is configured, second factors are disabled then
+func mustSetupSecretBox(log *slog.Logger, cfg config.MFAConfig) ownerCtl.SecretBox {
+	if cfg.SecretKey == "" {
+		log.Warn("mfa secret key is not configured, second factors are disabled")
+		return nil
+	}
+
+	box, err := secretbox.NewFromBase64(cfg.SecretKey)
+	if err != nil {
+		log.Error("failed to init mfa secret box")
+		panic(err)
+	}
+
+	return box
+}
+
+func mustSetupMailer(log *slog.Logger, cfg config.EmailConfig) ownerCtl.Mailer {
+	switch cfg.Sender {
+	case "smtp":
+		return mailer.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.User, cfg.SMTP.Password, cfg.From)
+	case "file":
+		outbox, err := mailer.NewOutbox(cfg.OutboxDir, cfg.From)
+		if err != nil {
+			log.Error("failed to init mail outbox")
+			panic(err)
+		}
+		log.Info("emails are written to the outbox", slog.String("dir", cfg.OutboxDir))
+		return outbox
+	}
+
+	panic("unknown email sender: " + cfg.Sender)
+}
+
+func mustSetupActionTokens(log *slog.Logger, cfg config.EmailConfig) *actiontoken.Signer {
+	if cfg.TokenSecret != "" {
+		return actiontoken.New([]byte(cfg.TokenSecret))
+	}
+
+	log.Warn("email token secret is not configured, links in emails expire on restart")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+
+	return actiontoken.New(key)
+}
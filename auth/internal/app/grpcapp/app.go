@@ -7,7 +7,12 @@ import (
 
 	"google.golang.org/grpc"
 
+	apprpc "github.com/viacheslavek/grpcauth/auth/internal/grpc/appCtl"
+	"github.com/viacheslavek/grpcauth/auth/internal/grpc/authz"
+	keyrpc "github.com/viacheslavek/grpcauth/auth/internal/grpc/keyCtl"
+	orgrpc "github.com/viacheslavek/grpcauth/auth/internal/grpc/orgCtl"
 	ownerrpc "github.com/viacheslavek/grpcauth/auth/internal/grpc/ownerCtl"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
 )
 
 type App struct {
@@ -16,10 +21,17 @@ type App struct {
 	port       int
 }
 
-func New(log *slog.Logger, ownerService ownerrpc.OwnerCtl, port int) *App {
-	gRPCServer := grpc.NewServer()
+func New(
+	log *slog.Logger,
+	ownerService ownerrpc.OwnerCtl, appService apprpc.AppCtl, keyService keyrpc.KeyCtl, orgService orgrpc.OrgCtl,
+	port int,
+) *App {
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(authz.Interceptor(ownerService, log)))
 
 	ownerrpc.Register(gRPCServer, ownerService, log)
+	apprpc.Register(gRPCServer, appService, log)
+	keyrpc.Register(gRPCServer, keyService, log)
+	orgrpc.Register(gRPCServer, orgService, log)
 
 	return &App{
 		log:        log,
@@ -30,7 +42,7 @@ func New(log *slog.Logger, ownerService ownerrpc.OwnerCtl, port int) *App {
 
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
-		a.log.Error("failed to run app server", err.Error())
+		a.log.Error("failed to run app server", sl.Err(err))
 		panic(err)
 	}
 }
@@ -0,0 +1,85 @@
+package httpapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+)
+
+type JWKSProvider interface {
+	JWKS() jwt.JWKS
+}
+
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+	port       int
+}
+
+func New(log *slog.Logger, jwksProvider JWKSProvider, port int, timeout time.Duration) *App {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler(log, jwksProvider))
+
+	return &App{
+		log: log,
+		httpServer: &http.Server{
+			Addr:         fmt.Sprintf(":%d", port),
+			Handler:      mux,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		},
+		port: port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		a.log.Error("failed to run http server", sl.Err(err))
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("port", a.port),
+	)
+
+	log.Info("http server is running", slog.String("addr", a.httpServer.Addr))
+
+	if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s failed serve http: %w", op, err)
+	}
+
+	return nil
+}
+
+func (a *App) Stop(ctx context.Context) {
+	const op = "httpapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("Stopping http server", slog.Int("port", a.port))
+
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		a.log.Error("failed to stop http server", sl.Err(err))
+	}
+}
+
+func jwksHandler(log *slog.Logger, jwksProvider JWKSProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		if err := json.NewEncoder(w).Encode(jwksProvider.JWKS()); err != nil {
+			log.Error("failed to write jwks", sl.Err(err))
+		}
+	}
+}
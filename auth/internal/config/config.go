@@ -9,13 +9,28 @@ import (
 	"github.com/joho/godotenv"
 )
 
-const defaultConfigPath = "config/local.yaml"
+const (
+	defaultConfigPath = "config/local.yaml"
 
+	envProd = "prod"
+)
+
+// Config Relative paths of files and directories are relative to the working directory
 type Config struct {
-	Env      string        `yaml:"env"`
-	DB       StorageConfig `yaml:"storage"`
-	GRPC     GRPCConfig    `yaml:"grpc"`
-	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	Env             string               `yaml:"env"`
+	DB              StorageConfig        `yaml:"storage"`
+	GRPC            GRPCConfig           `yaml:"grpc"`
+	HTTP            HTTPConfig           `yaml:"http"`
+	JWT             JWTConfig            `yaml:"jwt"`
+	MFA             MFAConfig            `yaml:"mfa"`
+	Email           EmailConfig          `yaml:"email"`
+	PasswordHash    PasswordHashConfig   `yaml:"password_hash"`
+	LoginLimit      LoginLimitConfig     `yaml:"login_limit"`
+	PasswordPolicy  PasswordPolicyConfig `yaml:"password_policy"`
+	RBAC            RBACConfig           `yaml:"rbac"`
+	Listing         ListingConfig        `yaml:"listing"`
+	TokenTTL        time.Duration        `yaml:"token_ttl" env-default:"1h"`
+	RefreshTokenTTL time.Duration        `yaml:"refresh_token_ttl" env-default:"720h"`
 }
 
 type StorageConfig struct {
@@ -32,6 +47,139 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+type HTTPConfig struct {
+	Port    int           `yaml:"port" env-default:"8080"`
+	Timeout time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
+// JWTConfig Without keys tokens are signed with the secret of their app (HS256).
+// An enabled key ring replaces the configured keys. With keys HS256 tokens are rejected,
+// LegacyHS256Until (RFC 3339) keeps them verifying until then while switching to keys
+type JWTConfig struct {
+	Keys             []SigningKeyConfig `yaml:"keys"`
+	KeyRing          KeyRingConfig      `yaml:"key_ring"`
+	LegacyHS256Until time.Time          `yaml:"legacy_hs256_until" env:"JWT_LEGACY_HS256_UNTIL"`
+}
+
+// KeyRingConfig Keys are generated and kept in the database. A rotated key keeps
+// verifying tokens for RetireAfter, RotateEvery of zero means manual rotation only.
+// SecretKey is a base64 encoded 32 byte key encrypting the private keys, the ring needs it
+type KeyRingConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Algorithm   string        `yaml:"algorithm" env-default:"EdDSA"`
+	RetireAfter time.Duration `yaml:"retire_after" env-default:"24h"`
+	RotateEvery time.Duration `yaml:"rotate_every"`
+	SecretKey   string        `yaml:"secret_key" env:"JWT_KEY_RING_SECRET_KEY"`
+}
+
+// SigningKeyConfig The first key signs tokens, the rest only verify them.
+// Algorithm is one of RS256, ES256 or EdDSA, Path points to a PEM private key
+type SigningKeyConfig struct {
+	Id        string `yaml:"id"`
+	Algorithm string `yaml:"algorithm"`
+	Path      string `yaml:"path"`
+}
+
+// MFAConfig SecretKey is a base64 encoded 32 byte key encrypting TOTP secrets,
+// without it owners can't enroll a second factor
+type MFAConfig struct {
+	Issuer       string        `yaml:"issuer" env-default:"grpcauth"`
+	SecretKey    string        `yaml:"secret_key" env:"MFA_SECRET_KEY"`
+	ChallengeTTL time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+}
+
+// PasswordHashConfig Algorithm of new hashes is "argon2id" or "bcrypt", Memory is in KiB.
+// Hashes made with other settings are replaced on the next login of the owner.
+// PepperFile holds "<version>:<base64 key>" lines, the highest version peppers new hashes,
+// a retired version has to stay in the file until no hash uses it
+type PasswordHashConfig struct {
+	Algorithm   string `yaml:"algorithm" env-default:"argon2id"`
+	BcryptCost  int    `yaml:"bcrypt_cost" env-default:"10"`
+	Memory      uint32 `yaml:"memory" env-default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env-default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"2"`
+	PepperFile  string `yaml:"pepper_file" env:"PASSWORD_PEPPER_FILE"`
+}
+
+// LoginLimitConfig Failed logins are counted per login and per client ip within Window.
+// From BackoffAfter failures of a login the next attempt waits BaseDelay doubled on every
+// failure up to MaxDelay, from LockoutAfter failures the login is locked for LockoutDuration.
+// A client ip gets the same backoff from IPBackoffAfter failures and is never locked
+type LoginLimitConfig struct {
+	Window          time.Duration `yaml:"window" env-default:"15m"`
+	BackoffAfter    int           `yaml:"backoff_after" env-default:"3"`
+	BaseDelay       time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay        time.Duration `yaml:"max_delay" env-default:"1m"`
+	LockoutAfter    int           `yaml:"lockout_after" env-default:"10"`
+	LockoutDuration time.Duration `yaml:"lockout_duration" env-default:"30m"`
+	IPBackoffAfter  int           `yaml:"ip_backoff_after" env-default:"50"`
+}
+
+// PasswordPolicyConfig Rules of new passwords, zero limits and unset requirements are not checked.
+// MinEntropy is in bits, ForbidPersonal rejects passwords containing the login or the email.
+// BreachedList is a file of common passwords or SHA-1 digests, BreachedHIBPDir holds range files
+// in the Have I Been Pwned layout, both are loaded into a bloom filter with BreachedFalsePositive rate.
+// The ranges are optional, the full corpus takes a while and much memory to load at startup.
+// HistorySize is the number of last passwords, the current one included, a new password has to differ from
+type PasswordPolicyConfig struct {
+	MinLength             int      `yaml:"min_length" env-default:"8"`
+	MaxLength             int      `yaml:"max_length" env-default:"128"`
+	RequireLetter         bool     `yaml:"require_letter"`
+	RequireLower          bool     `yaml:"require_lower"`
+	RequireUpper          bool     `yaml:"require_upper"`
+	RequireDigit          bool     `yaml:"require_digit"`
+	RequireSymbol         bool     `yaml:"require_symbol"`
+	MaxRepeated           int      `yaml:"max_repeated"`
+	MinEntropy            float64  `yaml:"min_entropy"`
+	ForbidPersonal        bool     `yaml:"forbid_personal"`
+	ForbiddenSubstrings   []string `yaml:"forbidden_substrings"`
+	BreachedList          string   `yaml:"breached_list" env:"PASSWORD_BREACHED_LIST"`
+	BreachedHIBPDir       string   `yaml:"breached_hibp_dir" env:"PASSWORD_BREACHED_HIBP_DIR"`
+	BreachedFalsePositive float64  `yaml:"breached_false_positive" env-default:"0.001"`
+	HistorySize           int      `yaml:"history_size"`
+}
+
+// RBACConfig The admin owner is created on startup unless it exists and gets the admin role,
+// without AdminLogin roles are only assigned by existing admins.
+// AdminApp is created with the admin for it to log in to, apps are only created by admins
+type RBACConfig struct {
+	AdminLogin    string `yaml:"admin_login"`
+	AdminEmail    string `yaml:"admin_email"`
+	AdminPassword string `yaml:"admin_password" env:"RBAC_ADMIN_PASSWORD"`
+	AdminApp      string `yaml:"admin_app" env-default:"admin-console"`
+}
+
+// ListingConfig Pages of listings are DefaultPageSize long unless a request asks for more,
+// requests are capped at MaxPageSize
+type ListingConfig struct {
+	DefaultPageSize int `yaml:"default_page_size" env-default:"50"`
+	MaxPageSize     int `yaml:"max_page_size" env-default:"500"`
+}
+
+// EmailConfig Sender is "smtp" or "file", the file sender writes messages to OutboxDir.
+// Without TokenSecret a random one is used and verification links die with the process,
+// prod requires it. An owner gets at most ResetLimit reset links within ResetTTL
+type EmailConfig struct {
+	Sender          string        `yaml:"sender" env-default:"file"`
+	From            string        `yaml:"from" env-default:"noreply@localhost"`
+	OutboxDir       string        `yaml:"outbox_dir" env-default:"outbox"`
+	SMTP            SMTPConfig    `yaml:"smtp"`
+	TokenSecret     string        `yaml:"token_secret" env:"EMAIL_TOKEN_SECRET"`
+	RequireVerified bool          `yaml:"require_verified"`
+	VerificationTTL time.Duration `yaml:"verification_ttl" env-default:"24h"`
+	VerificationURL string        `yaml:"verification_url"`
+	ResetTTL        time.Duration `yaml:"reset_ttl" env-default:"1h"`
+	ResetLimit      int           `yaml:"reset_limit" env-default:"3"`
+	ResetURL        string        `yaml:"reset_url"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
 func MustLoad() *Config {
 	configPath := fetchConfigPath()
 	if configPath == "" {
@@ -60,6 +208,10 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read env variables: " + err.Error())
 	}
 
+	if cfg.Env == envProd && cfg.Email.TokenSecret == "" {
+		panic("email token_secret is required in prod")
+	}
+
 	return &cfg
 }
 
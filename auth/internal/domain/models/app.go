@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+)
+
+type App struct {
+	id     int32
+	name   string
+	secret string
+}
+
+type AppKey struct {
+	Id   int32
+	Name string
+}
+
+func (a *App) SetId(id int32) error {
+	if id == emptyId {
+		return validator.ErrEmptyParameter
+	}
+	if id < 0 {
+		return fmt.Errorf("id can't be less than zero, given %d", id)
+	}
+
+	a.id = id
+
+	return nil
+}
+
+func (a *App) SetName(name string) error {
+	if len(name) == 0 {
+		return validator.ErrEmptyParameter
+	}
+
+	if err := validator.ValidateAppName(name); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	a.name = name
+
+	return nil
+}
+
+func (a *App) SetSecret(secret string) {
+	a.secret = secret
+}
+
+func (a *App) Id() int32 {
+	return a.id
+}
+
+func (a *App) Name() string {
+	return a.name
+}
+
+func (a *App) Secret() string {
+	return a.secret
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Kinds of failed login counters, the MFA counter of an owner counts wrong second factor codes
+const (
+	LoginAttemptLogin = "login"
+	LoginAttemptIP    = "ip"
+	LoginAttemptMFA   = "mfa"
+)
+
+// LoginAttemptKey A failed login counter of a login, of a client ip or of the second factor of an owner
+type LoginAttemptKey struct {
+	Kind    string
+	Subject string
+}
+
+// LoginAttempts Failed logins of a counter, BlockedUntil is set once the counter
+// passes a limit and Locked marks a lockout of the account
+type LoginAttempts struct {
+	Key           LoginAttemptKey
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+	Locked        bool
+}
+
+// LoginBlockPolicy Returns how long a counter is blocked after the failures and whether the block
+// is a lockout, a zero delay means no block
+type LoginBlockPolicy func(failures int) (time.Duration, bool)
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// TOTP The TOTP factor of an owner, the secret is encrypted.
+// Only a confirmed factor is asked for on login
+type TOTP struct {
+	OwnerId      int64
+	Secret       []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+// TOTPEnrollment A pending TOTP secret with the recovery codes issued with it
+type TOTPEnrollment struct {
+	URI           string
+	Secret        string
+	RecoveryCodes []string
+}
+
+// RecoveryCode A single use code replacing the TOTP code, only its bcrypt hash is stored
+type RecoveryCode struct {
+	Id       int64
+	OwnerId  int64
+	CodeHash []byte
+}
+
+// MFAChallenge Issued by a login of an owner with a second factor, only the hash
+// of the opaque value is stored. It is exchanged for tokens by a valid code
+type MFAChallenge struct {
+	Id        int64
+	TokenHash []byte
+	OwnerId   int64
+	AppId     int32
+	OrgId     int64
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
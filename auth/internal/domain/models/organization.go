@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Roles of members within an organization, they are independent of the owner roles
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization A customer of the platform grouping owners.
+// Role is the role of the owner the organizations are listed for,
+// Pending is set while the owner is invited and has not accepted yet.
+// An organization with ScopedAccounts creates accounts of its own
+type Organization struct {
+	Id             int64
+	Name           string
+	Slug           string
+	CreatedAt      time.Time
+	Role           string
+	Pending        bool
+	ScopedAccounts bool
+}
+
+// Membership An owner within an organization, ExternalId is the id of the member
+// in the systems of the organization and is unique within it.
+// A pending member is invited and becomes a member once it accepts
+type Membership struct {
+	OrgId      int64
+	OwnerId    int64
+	Login      string
+	Email      string
+	Role       string
+	ExternalId string
+	JoinedAt   time.Time
+	Pending    bool
+}
+
+// ManagesMembers Reports whether the member may invite and remove members
+func (m Membership) ManagesMembers() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+func ValidOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
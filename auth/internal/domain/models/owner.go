@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -15,13 +16,42 @@ type Owner struct {
 	login    string
 	password string
 	passHash []byte
+
+	displayName string
+
+	// orgId is the organization the account belongs to, zero for owners without one
+	orgId int64
+
+	pepperVersion int
+
+	emailVerified     bool
+	passwordChangedAt time.Time
+	createdAt         time.Time
+	locked            bool
+
+	mfaEnabled        bool
+	recoveryCodesLeft int
+
+	roles []string
 }
 
+// OwnerKey Names an owner by id, by login or by email. The login and the email are looked up
+// among the accounts of the organization OrgId, zero for owners without an organization
 type OwnerKey struct {
 	Id    int64
 	Login string
+	Email string
+	OrgId int64
 }
 
+// Fields of an owner an update changes, they are the paths of the update mask
+const (
+	OwnerFieldEmail       = "email"
+	OwnerFieldLogin       = "login"
+	OwnerFieldPassword    = "password"
+	OwnerFieldDisplayName = "display_name"
+)
+
 const emptyId = 0
 
 func (o *Owner) SetId(id int64) error {
@@ -70,19 +100,82 @@ func (o *Owner) SetPassword(password string) error {
 		return validator.ErrEmptyParameter
 	}
 
-	if err := validator.ValidatePassword(password); err != nil {
+	// The login and the email are checked when they are set before the password,
+	// the error is returned as is to tell a breached password from a policy violation
+	if err := validator.ValidatePassword(password, o.login, o.email); err != nil {
+		return err
+	}
+
+	o.password = password
+
+	return nil
+}
+
+// SetDisplayName Sets the name shown instead of the login, an owner without one has it empty
+func (o *Owner) SetDisplayName(name string) error {
+	if len(name) == 0 {
+		return validator.ErrEmptyParameter
+	}
+
+	if err := validator.ValidateDisplayName(name); err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	o.displayName = name
+
+	return nil
+}
+
+// SetLoginPassword Sets the password an owner logs in with, it is only compared with the stored hash.
+// The policy applies to new passwords, a stored password made under an older policy still logs in
+func (o *Owner) SetLoginPassword(password string) error {
+	if len(password) == 0 {
+		return validator.ErrEmptyParameter
+	}
+
 	o.password = password
 
 	return nil
 }
 
+// SetOrgId Makes the owner an account of the organization, its login and email are unique within it
+func (o *Owner) SetOrgId(orgId int64) {
+	o.orgId = orgId
+}
+
 func (o *Owner) SetPassHash(passHash []byte) {
 	o.passHash = passHash
 }
 
+// SetPepperVersion Sets the version of the pepper the password hash is made with
+func (o *Owner) SetPepperVersion(version int) {
+	o.pepperVersion = version
+}
+
+func (o *Owner) SetEmailVerified(verified bool) {
+	o.emailVerified = verified
+}
+
+// SetPasswordChangedAt Sets the time of the last password change, tokens issued before it are rejected
+func (o *Owner) SetPasswordChangedAt(changedAt time.Time) {
+	o.passwordChangedAt = changedAt
+}
+
+func (o *Owner) SetCreatedAt(createdAt time.Time) {
+	o.createdAt = createdAt
+}
+
+// SetLocked Marks the logins of the owner as locked out after failed attempts
+func (o *Owner) SetLocked(locked bool) {
+	o.locked = locked
+}
+
+// SetMFA Sets the second factor state shown in the owner view
+func (o *Owner) SetMFA(enabled bool, recoveryCodesLeft int) {
+	o.mfaEnabled = enabled
+	o.recoveryCodesLeft = recoveryCodesLeft
+}
+
 func (o *Owner) Id() int64 {
 	return o.id
 }
@@ -95,6 +188,14 @@ func (o *Owner) Email() string {
 	return o.email
 }
 
+func (o *Owner) DisplayName() string {
+	return o.displayName
+}
+
+func (o *Owner) OrgId() int64 {
+	return o.orgId
+}
+
 func (o *Owner) Password() string {
 	return o.password
 }
@@ -102,3 +203,41 @@ func (o *Owner) Password() string {
 func (o *Owner) PassHash() []byte {
 	return o.passHash
 }
+
+func (o *Owner) PepperVersion() int {
+	return o.pepperVersion
+}
+
+func (o *Owner) EmailVerified() bool {
+	return o.emailVerified
+}
+
+// PasswordChangedAt Returns the zero time when the password has never been changed
+func (o *Owner) PasswordChangedAt() time.Time {
+	return o.passwordChangedAt
+}
+
+func (o *Owner) CreatedAt() time.Time {
+	return o.createdAt
+}
+
+func (o *Owner) Locked() bool {
+	return o.locked
+}
+
+func (o *Owner) MFAEnabled() bool {
+	return o.mfaEnabled
+}
+
+func (o *Owner) RecoveryCodesLeft() int {
+	return o.recoveryCodesLeft
+}
+
+// SetRoles Sets the names of the roles assigned to the owner, they are embedded in access tokens
+func (o *Owner) SetRoles(roles []string) {
+	o.roles = roles
+}
+
+func (o *Owner) Roles() []string {
+	return o.roles
+}
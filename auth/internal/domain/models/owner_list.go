@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Fields owners are sorted by, the id breaks ties of the other fields
+const (
+	OwnerSortId        = "id"
+	OwnerSortCreatedAt = "created_at"
+	OwnerSortLogin     = "login"
+	OwnerSortEmail     = "email"
+)
+
+// OwnerFilter Narrows a listing of owners, zero values do not filter.
+// CreatedAfter is inclusive and CreatedBefore is exclusive
+type OwnerFilter struct {
+	LoginPrefix   string
+	EmailPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Verified      *bool
+	Locked        *bool
+}
+
+// OwnerCursor The position after the last owner of a page, Sort and Desc
+// are the order the page is listed in and must match the next request
+type OwnerCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	Id        int64     `json:"id"`
+	Login     string    `json:"l,omitempty"`
+	Email     string    `json:"e,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+}
+
+// OwnerListQuery A page of owners, After is nil for the first page
+type OwnerListQuery struct {
+	Filter OwnerFilter
+	Sort   string
+	Desc   bool
+	Limit  int
+	After  *OwnerCursor
+}
+
+// CursorAfter Returns the cursor positioned after the owner in the order of the query
+func (q OwnerListQuery) CursorAfter(owner Owner) OwnerCursor {
+	cursor := OwnerCursor{Sort: q.Sort, Desc: q.Desc, Id: owner.Id()}
+	switch q.Sort {
+	case OwnerSortLogin:
+		cursor.Login = owner.Login()
+	case OwnerSortEmail:
+		cursor.Email = owner.Email()
+	case OwnerSortCreatedAt:
+		cursor.CreatedAt = owner.CreatedAt()
+	}
+	return cursor
+}
+
+// OwnerSearch A fuzzy search of owners by login or email,
+// OwnerId restricts it to a single owner when not zero
+type OwnerSearch struct {
+	Text    string
+	Limit   int
+	OwnerId int64
+}
+
+// OwnerMatch An owner found by a search with the similarity of its best matching field, from 0 to 1
+type OwnerMatch struct {
+	Owner Owner
+	Score float32
+}
@@ -0,0 +1,45 @@
+package models
+
+import "slices"
+
+// Permissions checked by the endpoints, roles grant them in the roles tables
+const (
+	PermOwnersRead = "owners.read"
+	// PermOwnersReadSecrets Grants reading password hashes of owners
+	PermOwnersReadSecrets = "owners.read_secrets"
+	PermOwnersWrite       = "owners.write"
+	PermOwnersDelete      = "owners.delete"
+	PermOwnersUnlock      = "owners.unlock"
+	PermRolesRead         = "roles.read"
+	PermRolesWrite        = "roles.write"
+	PermAppsRead          = "apps.read"
+	PermAppsWrite         = "apps.write"
+	PermKeysRead          = "keys.read"
+	PermKeysWrite         = "keys.write"
+	// PermTokensIntrospect Grants introspecting tokens of other owners, services checking tokens hold it
+	PermTokensIntrospect = "tokens.introspect"
+)
+
+// RoleAdmin The role granted every permission, the bootstrap admin gets it on startup
+const RoleAdmin = "admin"
+
+// Role A named set of permissions assigned to owners
+type Role struct {
+	Id          int32
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// Caller The owner of the access token a request is made with.
+// Login is empty for an account of an organization, its login does not name it outside the organization
+type Caller struct {
+	Id          int64
+	Login       string
+	Roles       []string
+	Permissions []string
+}
+
+func (c Caller) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
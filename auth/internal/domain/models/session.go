@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ClientInfo Describes the client a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session A login of an owner, its id is shared by the refresh token family
+// and the sid claim of every access token issued for it
+type Session struct {
+	Id      string
+	OwnerId int64
+	AppId   int32
+	// OrgId The organization the session is logged in to, zero for none
+	OrgId     int64
+	Client    ClientInfo
+	CreatedAt time.Time
+	LastSeen  time.Time
+	RevokedAt *time.Time
+}
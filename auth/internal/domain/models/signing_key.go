@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Signing key states: an active key signs and verifies tokens, a retiring key
+// only verifies tokens it signed before rotation, a retired key is not used
+const (
+	SigningKeyActive   = "active"
+	SigningKeyRetiring = "retiring"
+	SigningKeyRetired  = "retired"
+)
+
+// SigningKey A persisted signing key, PrivateKey is a PEM encoded PKCS #8 key,
+// sealed by the secret box when Encrypted. Keys stored before encryption are plaintext
+type SigningKey struct {
+	Id         string
+	Algorithm  string
+	PrivateKey []byte
+	Encrypted  bool
+	State      string
+	CreatedAt  time.Time
+	RetiringAt *time.Time
+	RetiredAt  *time.Time
+}
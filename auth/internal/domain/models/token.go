@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Tokens A pair issued to an owner on login and on every refresh.
+// A login of an owner with a second factor gets only the MFAToken
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
+// RefreshToken A persisted refresh token, only the hash of the opaque value is stored.
+// Tokens rotated from one login share the same family
+type RefreshToken struct {
+	Id        int64
+	TokenHash []byte
+	FamilyId  string
+	OwnerId   int64
+	AppId     int32
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// PasswordReset A single use token emailed to an owner who forgot the password,
+// only the hash of the opaque value is stored
+type PasswordReset struct {
+	Id        int64
+	TokenHash []byte
+	OwnerId   int64
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// PasswordHistoryEntry A previous password hash of an owner kept to prevent reuse
+type PasswordHistoryEntry struct {
+	Id            int64
+	OwnerId       int64
+	PassHash      []byte
+	PepperVersion int
+	CreatedAt     time.Time
+}
@@ -0,0 +1,227 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy Rules a password has to follow. Zero limits are not checked.
+// MinEntropy is estimated in bits as the length times log2 of the size of the
+// character classes the password uses
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLetter bool
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MaxRepeated The longest run of one character
+	MaxRepeated int
+	MinEntropy  float64
+	// ForbidPersonal Rejects passwords containing the login or the email of the owner
+	ForbidPersonal bool
+	// ForbiddenSubstrings Are matched case-insensitively
+	ForbiddenSubstrings []string
+	// Breached Known breached and common passwords, nil skips the check.
+	// Only passwords being set are checked, so owners with a listed password still log in to change it
+	Breached BreachedPasswords
+}
+
+// BreachedPasswords A list of passwords known from breaches or too common to be used
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+// DefaultPasswordPolicy Rules used until another policy is set
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     8,
+	MaxLength:     128,
+	RequireLetter: true,
+	RequireDigit:  true,
+}
+
+var (
+	ErrPasswordPolicy   = errors.New("password does not follow the policy")
+	ErrBreachedPassword = errors.New("password is known from breaches or too common")
+)
+
+// minPersonalLen Shorter logins and email parts are too common to forbid
+const minPersonalLen = 3
+
+// Sizes of the character classes used by the entropy estimate
+const (
+	lowerPoolSize  = 26
+	upperPoolSize  = 26
+	digitPoolSize  = 10
+	symbolPoolSize = 33
+	otherPoolSize  = 100
+)
+
+var passwordPolicy atomic.Pointer[PasswordPolicy]
+
+func init() {
+	SetPasswordPolicy(DefaultPasswordPolicy)
+}
+
+// SetPasswordPolicy Replaces the policy checked by ValidatePassword, it is set once on startup
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicy.Store(&policy)
+}
+
+func CurrentPasswordPolicy() PasswordPolicy {
+	return *passwordPolicy.Load()
+}
+
+// ValidatePassword Checks the password against the current policy,
+// personal are the login and the email of the owner, empty ones are skipped
+func ValidatePassword(password string, personal ...string) error {
+	return CurrentPasswordPolicy().Validate(password, personal...)
+}
+
+// Validate Returns the first rule the password breaks wrapped in ErrPasswordPolicy,
+// a password from the breached list is reported with ErrBreachedPassword
+func (p PasswordPolicy) Validate(password string, personal ...string) error {
+	if password == "" {
+		return fmt.Errorf("%w: cannot be blank", ErrPasswordPolicy)
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrPasswordPolicy, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: must be at most %d characters long", ErrPasswordPolicy, p.MaxLength)
+	}
+
+	classes := passwordClasses(password)
+	switch {
+	case p.RequireLetter && !classes.lower && !classes.upper:
+		return fmt.Errorf("%w: must contain at least one letter", ErrPasswordPolicy)
+	case p.RequireLower && !classes.lower:
+		return fmt.Errorf("%w: must contain at least one lowercase letter", ErrPasswordPolicy)
+	case p.RequireUpper && !classes.upper:
+		return fmt.Errorf("%w: must contain at least one uppercase letter", ErrPasswordPolicy)
+	case p.RequireDigit && !classes.digit:
+		return fmt.Errorf("%w: must contain at least one digit", ErrPasswordPolicy)
+	case p.RequireSymbol && !classes.symbol:
+		return fmt.Errorf("%w: must contain at least one symbol", ErrPasswordPolicy)
+	}
+
+	if p.MaxRepeated > 0 && longestRun(password) > p.MaxRepeated {
+		return fmt.Errorf("%w: must not repeat a character more than %d times in a row", ErrPasswordPolicy, p.MaxRepeated)
+	}
+
+	if p.MinEntropy > 0 && PasswordEntropy(password) < p.MinEntropy {
+		return fmt.Errorf("%w: is too easy to guess", ErrPasswordPolicy)
+	}
+
+	lower := strings.ToLower(password)
+	if p.ForbidPersonal {
+		for _, value := range personalParts(personal) {
+			if strings.Contains(lower, value) {
+				return fmt.Errorf("%w: must not contain the login or the email", ErrPasswordPolicy)
+			}
+		}
+	}
+	for _, forbidden := range p.ForbiddenSubstrings {
+		if forbidden != "" && strings.Contains(lower, strings.ToLower(forbidden)) {
+			return fmt.Errorf("%w: must not contain %q", ErrPasswordPolicy, forbidden)
+		}
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return ErrBreachedPassword
+	}
+
+	return nil
+}
+
+// PasswordEntropy Estimates the entropy of the password in bits
+func PasswordEntropy(password string) float64 {
+	classes := passwordClasses(password)
+
+	pool := 0
+	if classes.lower {
+		pool += lowerPoolSize
+	}
+	if classes.upper {
+		pool += upperPoolSize
+	}
+	if classes.digit {
+		pool += digitPoolSize
+	}
+	if classes.symbol {
+		pool += symbolPoolSize
+	}
+	if classes.other {
+		pool += otherPoolSize
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
+
+type charClasses struct {
+	lower, upper, digit, symbol, other bool
+}
+
+func passwordClasses(password string) charClasses {
+	var classes charClasses
+	for _, r := range password {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLower(r):
+			classes.lower = true
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			classes.upper = true
+		case unicode.IsDigit(r):
+			classes.digit = true
+		case r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' '):
+			classes.symbol = true
+		case unicode.IsLetter(r):
+			classes.other = true
+			classes.lower = classes.lower || unicode.IsLower(r)
+			classes.upper = classes.upper || unicode.IsUpper(r)
+		default:
+			classes.other = true
+		}
+	}
+	return classes
+}
+
+func longestRun(password string) int {
+	longest, run := 0, 0
+	var prev rune = -1
+	for _, r := range password {
+		if r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// personalParts Returns the lowercase login, email and email local part long enough to be checked
+func personalParts(personal []string) []string {
+	parts := make([]string, 0, len(personal)*2)
+	for _, value := range personal {
+		value = strings.ToLower(value)
+		if local, _, ok := strings.Cut(value, "@"); ok && len(local) >= minPersonalLen {
+			parts = append(parts, local)
+		}
+		if len(value) >= minPersonalLen {
+			parts = append(parts, value)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strict := PasswordPolicy{
+		MinLength:           10,
+		MaxLength:           20,
+		RequireLower:        true,
+		RequireUpper:        true,
+		RequireDigit:        true,
+		RequireSymbol:       true,
+		MaxRepeated:         2,
+		MinEntropy:          70,
+		ForbidPersonal:      true,
+		ForbiddenSubstrings: []string{"grpcauth"},
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		personal    []string
+		expectError bool
+	}{
+		{"valid", "Correct-Horse7", nil, false},
+		{"too short", "Sh0rt-Pw", nil, true},
+		{"too long", "Correct-Horse7-Battery-Staple", nil, true},
+		{"no lowercase", "CORRECT-HORSE7", nil, true},
+		{"no uppercase", "correct-horse7", nil, true},
+		{"no digit", "Correct-Horse!", nil, true},
+		{"no symbol", "CorrectHorse7", nil, true},
+		{"repeated", "Correct-Hooorse7", nil, true},
+		{"contains login", "Xx-Alice99-Yy", []string{"alice99", "bob@example.com"}, true},
+		{"contains email local part", "Bobby-Tables7", []string{"alice99", "bobby@example.com"}, true},
+		{"short login is not checked", "Correct-Horse7", []string{"co", ""}, false},
+		{"forbidden substring", "My-GRPCAUTH-7x", nil, true},
+		{"low entropy", "Aa1!Aa1!Aa", nil, true},
+	}
+
+	for _, test := range tests {
+		err := strict.Validate(test.password, test.personal...)
+		if test.expectError && !errors.Is(err, ErrPasswordPolicy) {
+			t.Errorf("%s: expected ErrPasswordPolicy for %q, got: %v", test.name, test.password, err)
+		} else if !test.expectError && err != nil {
+			t.Errorf("%s: did not expect error for %q, but got: %v", test.name, test.password, err)
+		}
+	}
+}
+
+func TestSetPasswordPolicy(t *testing.T) {
+	t.Cleanup(func() { SetPasswordPolicy(DefaultPasswordPolicy) })
+
+	if err := ValidatePassword("password123"); err != nil {
+		t.Errorf("did not expect error with the default policy, but got: %v", err)
+	}
+
+	SetPasswordPolicy(PasswordPolicy{MinLength: 12})
+	if err := ValidatePassword("password123"); err == nil {
+		t.Errorf("expected error with a longer minimal length")
+	}
+	if CurrentPasswordPolicy().MinLength != 12 {
+		t.Errorf("expected the set policy, got %+v", CurrentPasswordPolicy())
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	if PasswordEntropy("") != 0 {
+		t.Errorf("expected no entropy for an empty password")
+	}
+	if PasswordEntropy("aaaaaaaa") >= PasswordEntropy("aA1!aA1!") {
+		t.Errorf("expected more classes to give more entropy")
+	}
+}
+
+type breachedList map[string]bool
+
+func (l breachedList) Contains(password string) bool {
+	return l[password]
+}
+
+func TestPasswordPolicy_ValidateBreached(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	policy.Breached = breachedList{"qwerty123": true}
+
+	if err := policy.Validate("qwerty123"); !errors.Is(err, ErrBreachedPassword) || errors.Is(err, ErrPasswordPolicy) {
+		t.Errorf("expected only ErrBreachedPassword for a breached password, got: %v", err)
+	}
+	if err := policy.Validate("qwerty"); !errors.Is(err, ErrPasswordPolicy) {
+		t.Errorf("expected policy rules to be checked first, got: %v", err)
+	}
+	if err := policy.Validate("Correct-Horse7"); err != nil {
+		t.Errorf("did not expect error for a password not in the list, but got: %v", err)
+	}
+}
@@ -20,20 +20,46 @@ func ValidateEmail(email string) error {
 	)
 }
 
-func ValidatePassword(password string) error {
+func ValidateLogin(login string) error {
 	return validation.Validate(
-		password,
+		login,
 		validation.Required,
-		validation.Length(8, 0),
-		validation.Match(regexp.MustCompile(`[a-zA-Z]`)).Error("must contain at least one letter"),
-		validation.Match(regexp.MustCompile(`[0-9]`)).Error("must contain at least one digit"),
+		validation.Match(regexp.MustCompile("^[a-zA-Z0-9]+$")).Error("must be alphanumeric"),
 	)
 }
 
-func ValidateLogin(login string) error {
+func ValidateDisplayName(name string) error {
 	return validation.Validate(
-		login,
+		name,
 		validation.Required,
-		validation.Match(regexp.MustCompile("^[a-zA-Z0-9]+$")).Error("must be alphanumeric"),
+		validation.RuneLength(1, 64),
+	)
+}
+
+func ValidateAppName(name string) error {
+	return validation.Validate(
+		name,
+		validation.Required,
+		validation.Length(1, 64),
+		validation.Match(regexp.MustCompile("^[a-zA-Z0-9_-]+$")).Error("must contain only letters, digits, '_' or '-'"),
+	)
+}
+
+func ValidateOrgName(name string) error {
+	return validation.Validate(
+		name,
+		validation.Required,
+		validation.RuneLength(1, 128),
+	)
+}
+
+// ValidateOrgSlug A slug is lowercase so that organizations differing only in case can not coexist
+func ValidateOrgSlug(slug string) error {
+	return validation.Validate(
+		slug,
+		validation.Required,
+		validation.Length(2, 64),
+		validation.Match(regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")).
+			Error("must contain only lowercase letters, digits and single inner '-'"),
 	)
 }
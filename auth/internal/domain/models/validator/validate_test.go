@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -66,3 +67,71 @@ func TestValidateLogin(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateAppName(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectError bool
+	}{
+		{"billing-service", false},
+		{"mobile_app2", false},
+		{"my app", true}, // invalid character
+		{"app!", true},   // invalid character
+		{"", true},       // empty
+	}
+
+	for _, test := range tests {
+		err := ValidateAppName(test.name)
+		if test.expectError && err == nil {
+			t.Errorf("Expected error for app name: %s, but got none", test.name)
+		} else if !test.expectError && err != nil {
+			t.Errorf("Did not expect error for app name: %s, but got: %v", test.name, err)
+		}
+	}
+}
+
+func TestValidateOrgSlug(t *testing.T) {
+	tests := []struct {
+		slug        string
+		expectError bool
+	}{
+		{"acme", false},
+		{"acme-corp-2", false},
+		{"Acme", true},       // uppercase
+		{"acme--corp", true}, // double dash
+		{"-acme", true},      // leading dash
+		{"a", true},          // too short
+		{"", true},           // empty
+	}
+
+	for _, test := range tests {
+		err := ValidateOrgSlug(test.slug)
+		if test.expectError && err == nil {
+			t.Errorf("Expected error for slug: %s, but got none", test.slug)
+		} else if !test.expectError && err != nil {
+			t.Errorf("Did not expect error for slug: %s, but got: %v", test.slug, err)
+		}
+	}
+}
+
+func TestValidateDisplayName(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectError bool
+	}{
+		{"Jane Doe", false},
+		{"Слава", false},
+		{strings.Repeat("ж", 64), false},
+		{strings.Repeat("ж", 65), true}, // too long
+		{"", true},                      // empty
+	}
+
+	for _, test := range tests {
+		err := ValidateDisplayName(test.name)
+		if test.expectError && err == nil {
+			t.Errorf("Expected error for display name: %s, but got none", test.name)
+		} else if !test.expectError && err != nil {
+			t.Errorf("Did not expect error for display name: %s, but got: %v", test.name, err)
+		}
+	}
+}
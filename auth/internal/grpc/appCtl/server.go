@@ -0,0 +1,219 @@
+package appCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/appCtl"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+type AppCtl interface {
+	CreateApp(ctx context.Context, app models.App) (models.App, error)
+	GetApp(ctx context.Context, app models.App) (models.App, error)
+	ListApps(ctx context.Context) ([]models.App, error)
+	UpdateApp(ctx context.Context, app models.App) error
+	DeleteApp(ctx context.Context, app models.App) error
+
+	RotateAppSecret(ctx context.Context, app models.App) (models.App, error)
+}
+
+type serverAPI struct {
+	authv1.UnimplementedAppControllerServer
+	actl AppCtl
+	lg   *slog.Logger
+}
+
+func Register(gRPC *grpc.Server, actl AppCtl, lg *slog.Logger) {
+	authv1.RegisterAppControllerServer(gRPC, &serverAPI{actl: actl, lg: lg})
+}
+
+// CreateApp Registers a client application by name and issues its secret
+func (s *serverAPI) CreateApp(
+	ctx context.Context, req *authv1.CreateAppRequest,
+) (*authv1.App, error) {
+	const op = "auth.CreateApp"
+
+	a := models.App{}
+	if err := a.SetName(req.GetName()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set name %v", op, err))
+	}
+
+	app, err := s.actl.CreateApp(ctx, a)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to create app", sl.Err(err))
+
+		if errors.Is(err, storage.ErrAppExists) {
+			return nil, status.Error(codes.AlreadyExists, "app already exists")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.App{Id: app.Id(), Name: app.Name(), Secret: app.Secret()}, nil
+}
+
+// GetApp Retrieves an application by ID or name without its secret
+func (s *serverAPI) GetApp(
+	ctx context.Context, req *authv1.GetAppRequest,
+) (*authv1.App, error) {
+	const op = "auth.GetApp"
+
+	a, errSK := appFromKey(req.GetId(), req.GetName())
+	if errSK != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %v", op, errSK))
+	}
+
+	app, err := s.actl.GetApp(ctx, a)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to get app", sl.Err(err))
+
+		if errors.Is(err, appCtl.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.App{Id: app.Id(), Name: app.Name()}, nil
+}
+
+// ListApps Retrieves all registered applications without their secrets
+func (s *serverAPI) ListApps(
+	ctx context.Context, _ *authv1.ListAppsRequest,
+) (*authv1.ListAppsResponse, error) {
+	const op = "auth.ListApps"
+
+	apps, err := s.actl.ListApps(ctx)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list apps", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	res := &authv1.ListAppsResponse{Apps: make([]*authv1.App, 0, len(apps))}
+	for _, app := range apps {
+		res.Apps = append(res.Apps, &authv1.App{Id: app.Id(), Name: app.Name()})
+	}
+
+	return res, nil
+}
+
+// UpdateApp Renames an application by ID
+func (s *serverAPI) UpdateApp(
+	ctx context.Context, req *authv1.UpdateAppRequest,
+) (*authv1.Response, error) {
+	const op = "auth.UpdateApp"
+
+	a := models.App{}
+	if err := a.SetId(req.GetId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, err))
+	}
+	if err := a.SetName(req.GetName()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set name %v", op, err))
+	}
+
+	if err := s.actl.UpdateApp(ctx, a); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to update app", sl.Err(err))
+
+		if errors.Is(err, appCtl.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+		if errors.Is(err, storage.ErrAppExists) {
+			return nil, status.Error(codes.AlreadyExists, "app already exists")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success update app"}, nil
+}
+
+// DeleteApp Deletes an application by ID or name
+func (s *serverAPI) DeleteApp(
+	ctx context.Context, req *authv1.DeleteAppRequest,
+) (*authv1.Response, error) {
+	const op = "auth.DeleteApp"
+
+	a, errSK := appFromKey(req.GetId(), req.GetName())
+	if errSK != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %v", op, errSK))
+	}
+
+	if err := s.actl.DeleteApp(ctx, a); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to delete app", sl.Err(err))
+
+		if errors.Is(err, appCtl.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success delete app"}, nil
+}
+
+// RotateAppSecret Replaces the application secret with a freshly generated one
+func (s *serverAPI) RotateAppSecret(
+	ctx context.Context, req *authv1.RotateAppSecretRequest,
+) (*authv1.App, error) {
+	const op = "auth.RotateAppSecret"
+
+	a := models.App{}
+	if err := a.SetId(req.GetId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, err))
+	}
+
+	app, err := s.actl.RotateAppSecret(ctx, a)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to rotate app secret", sl.Err(err))
+
+		if errors.Is(err, appCtl.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.App{Id: app.Id(), Name: app.Name(), Secret: app.Secret()}, nil
+}
+
+func appFromKey(id int32, name string) (models.App, error) {
+	a := models.App{}
+	errIdVal := a.SetId(id)
+	errNameVal := a.SetName(name)
+
+	if errors.Is(errIdVal, validator.ErrEmptyParameter) && errors.Is(errNameVal, validator.ErrEmptyParameter) {
+		return models.App{}, errors.New("empty all app parameters")
+	}
+	if errIdVal != nil && !errors.Is(errIdVal, validator.ErrEmptyParameter) {
+		return models.App{}, fmt.Errorf("failed set id %w", errIdVal)
+	}
+	if errNameVal != nil && !errors.Is(errNameVal, validator.ErrEmptyParameter) {
+		return models.App{}, fmt.Errorf("failed set name %w", errNameVal)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,147 @@
+// Package authz Authenticates the callers of the gRPC services and enforces the access rules of their RPCs
+package authz
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/grpcctx"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/ownerCtl"
+)
+
+// Authenticator Returns the owner of an access token with its permissions
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (models.Caller, error)
+}
+
+// accessRule The permission an RPC requires, self lets owners call it on themselves without the permission.
+// A rule without a permission admits every authenticated owner
+type accessRule struct {
+	permission string
+	self       func(req any, caller models.Caller) bool
+}
+
+// accessRules RPCs missing here are public or authenticate the caller by the token themselves
+var accessRules = map[string]accessRule{
+	ownerMethod("GetOwner"):   {permission: models.PermOwnersRead, self: selfByIdOrLogin},
+	ownerMethod("ListOwners"): {permission: models.PermOwnersRead},
+	// Callers without the permission to read owners only find themselves
+	ownerMethod("SearchOwners"): {},
+	ownerMethod("UpdateOwner"):  {permission: models.PermOwnersWrite, self: selfById},
+	ownerMethod("DeleteOwner"):  {permission: models.PermOwnersDelete, self: selfByIdOrLogin},
+	ownerMethod("UnlockOwner"):  {permission: models.PermOwnersUnlock},
+	ownerMethod("AssignRole"):   {permission: models.PermRolesWrite},
+	ownerMethod("RevokeRole"):   {permission: models.PermRolesWrite},
+	ownerMethod("ListRoles"):    {permission: models.PermRolesRead, self: selfByIdOrLogin},
+	// Introspection tells whether any token is valid, only trusted callers may ask (RFC 7662)
+	ownerMethod("IntrospectToken"): {permission: models.PermTokensIntrospect},
+
+	// Organizations check the role of the caller within the organization themselves
+	orgMethod("CreateOrganization"): {},
+	orgMethod("ListOrganizations"):  {},
+	orgMethod("ListMembers"):        {},
+	orgMethod("InviteMember"):       {},
+	orgMethod("AcceptInvitation"):   {},
+	orgMethod("CreateMember"):       {},
+	orgMethod("RemoveMember"):       {},
+
+	// App secrets sign tokens and signing keys sign every token, only admins manage them
+	appMethod("CreateApp"):        {permission: models.PermAppsWrite},
+	appMethod("GetApp"):           {permission: models.PermAppsRead},
+	appMethod("ListApps"):         {permission: models.PermAppsRead},
+	appMethod("UpdateApp"):        {permission: models.PermAppsWrite},
+	appMethod("DeleteApp"):        {permission: models.PermAppsWrite},
+	appMethod("RotateAppSecret"):  {permission: models.PermAppsWrite},
+	keyMethod("RotateSigningKey"): {permission: models.PermKeysWrite},
+	keyMethod("ListSigningKeys"):  {permission: models.PermKeysRead},
+}
+
+// Interceptor Enforces the access rules of the OwnerController, OrganizationController,
+// AppController and KeyController RPCs,
+// the authorized caller is passed to the handler in the context
+func Interceptor(authn Authenticator, lg *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		rule, ok := accessRules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		const op = "auth.Interceptor"
+
+		token, ok := grpcctx.BearerToken(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+
+		caller, err := authn.Authenticate(ctx, token)
+		if err != nil {
+			if errors.Is(err, ownerCtl.ErrInvalidToken) {
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+
+			lg.With(
+				slog.String("op", op),
+				slog.String("method", info.FullMethod),
+			).Error("failed to authenticate caller", sl.Err(err))
+
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+
+		if rule.permission != "" && !caller.HasPermission(rule.permission) &&
+			(rule.self == nil || !rule.self(req, caller)) {
+			lg.With(
+				slog.String("op", op),
+				slog.String("method", info.FullMethod),
+				slog.Int64("uid", caller.Id),
+			).Warn("permission denied", slog.String("permission", rule.permission))
+
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(grpcctx.WithCaller(ctx, caller), req)
+	}
+}
+
+func ownerMethod(name string) string {
+	return "/" + authv1.OwnerController_ServiceDesc.ServiceName + "/" + name
+}
+
+func orgMethod(name string) string {
+	return "/" + authv1.OrganizationController_ServiceDesc.ServiceName + "/" + name
+}
+
+func appMethod(name string) string {
+	return "/" + authv1.AppController_ServiceDesc.ServiceName + "/" + name
+}
+
+func keyMethod(name string) string {
+	return "/" + authv1.KeyController_ServiceDesc.ServiceName + "/" + name
+}
+
+// selfById Matches requests naming the caller by id, the login of such requests is a new value
+func selfById(req any, caller models.Caller) bool {
+	r, ok := req.(interface{ GetId() int64 })
+	return ok && r.GetId() == caller.Id
+}
+
+// selfByIdOrLogin Matches requests naming the caller by id, by login or by both
+func selfByIdOrLogin(req any, caller models.Caller) bool {
+	r, ok := req.(interface {
+		GetId() int64
+		GetLogin() string
+	})
+	if !ok || (r.GetId() == 0 && r.GetLogin() == "") {
+		return false
+	}
+	return (r.GetId() == 0 || r.GetId() == caller.Id) && (r.GetLogin() == "" || r.GetLogin() == caller.Login)
+}
@@ -0,0 +1,96 @@
+package keyCtl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/keyCtl"
+)
+
+type KeyCtl interface {
+	RotateSigningKey(ctx context.Context) (jwt.RingKey, error)
+	ListSigningKeys(ctx context.Context) ([]jwt.RingKey, error)
+}
+
+type serverAPI struct {
+	authv1.UnimplementedKeyControllerServer
+	kctl KeyCtl
+	lg   *slog.Logger
+}
+
+func Register(gRPC *grpc.Server, kctl KeyCtl, lg *slog.Logger) {
+	authv1.RegisterKeyControllerServer(gRPC, &serverAPI{kctl: kctl, lg: lg})
+}
+
+// RotateSigningKey Creates a new active signing key, the previous one keeps verifying tokens until retired
+func (s *serverAPI) RotateSigningKey(
+	ctx context.Context, _ *authv1.RotateSigningKeyRequest,
+) (*authv1.SigningKey, error) {
+	const op = "auth.RotateSigningKey"
+
+	key, err := s.kctl.RotateSigningKey(ctx)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to rotate signing key", sl.Err(err))
+
+		if errors.Is(err, keyCtl.ErrKeyRingDisabled) {
+			return nil, status.Error(codes.FailedPrecondition, "key ring is disabled")
+		}
+		if errors.Is(err, jwt.ErrSigningKeyConflict) {
+			return nil, status.Error(codes.Aborted, "signing key was rotated concurrently")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return toSigningKey(key), nil
+}
+
+// ListSigningKeys Retrieves the active and retiring signing keys without private parts
+func (s *serverAPI) ListSigningKeys(
+	ctx context.Context, _ *authv1.ListSigningKeysRequest,
+) (*authv1.ListSigningKeysResponse, error) {
+	const op = "auth.ListSigningKeys"
+
+	keys, err := s.kctl.ListSigningKeys(ctx)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list signing keys", sl.Err(err))
+
+		if errors.Is(err, keyCtl.ErrKeyRingDisabled) {
+			return nil, status.Error(codes.FailedPrecondition, "key ring is disabled")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	res := &authv1.ListSigningKeysResponse{Keys: make([]*authv1.SigningKey, 0, len(keys))}
+	for _, key := range keys {
+		res.Keys = append(res.Keys, toSigningKey(key))
+	}
+
+	return res, nil
+}
+
+func toSigningKey(key jwt.RingKey) *authv1.SigningKey {
+	res := &authv1.SigningKey{
+		Kid:       key.Id,
+		Alg:       key.Method.Alg(),
+		State:     key.State,
+		CreatedAt: key.CreatedAt.Unix(),
+	}
+	if key.RetiringAt != nil {
+		res.RetiringAt = key.RetiringAt.Unix()
+	}
+	return res
+}
@@ -0,0 +1,329 @@
+package orgCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/grpcctx"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/services/orgCtl"
+)
+
+type OrgCtl interface {
+	CreateOrganization(ctx context.Context, caller models.Caller, org models.Organization) (models.Organization, error)
+	ListOrganizations(ctx context.Context, caller models.Caller) ([]models.Organization, error)
+	ListMembers(ctx context.Context, caller models.Caller, orgId int64) ([]models.Membership, error)
+	InviteMember(ctx context.Context, caller models.Caller, member models.Membership, key models.OwnerKey) error
+	AcceptInvitation(ctx context.Context, caller models.Caller, orgId int64) (models.Organization, error)
+	CreateMember(
+		ctx context.Context, caller models.Caller, member models.Membership, owner models.Owner,
+	) (models.Membership, error)
+	RemoveMember(ctx context.Context, caller models.Caller, orgId int64, ownerId int64) error
+}
+
+type serverAPI struct {
+	authv1.UnimplementedOrganizationControllerServer
+	octl OrgCtl
+	lg   *slog.Logger
+}
+
+// Register The caller is authenticated by the authz interceptor
+func Register(gRPC *grpc.Server, octl OrgCtl, lg *slog.Logger) {
+	authv1.RegisterOrganizationControllerServer(gRPC, &serverAPI{octl: octl, lg: lg})
+}
+
+// CreateOrganization Creates an organization owned by the caller
+func (s *serverAPI) CreateOrganization(
+	ctx context.Context, req *authv1.CreateOrganizationRequest,
+) (*authv1.Organization, error) {
+	const op = "auth.CreateOrganization"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if err := validator.ValidateOrgName(req.GetName()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid name %v", op, err))
+	}
+	if err := validator.ValidateOrgSlug(req.GetSlug()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid slug %v", op, err))
+	}
+
+	org, err := s.octl.CreateOrganization(ctx, caller, models.Organization{
+		Name: req.GetName(), Slug: req.GetSlug(), ScopedAccounts: req.GetScopedAccounts(),
+	})
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to create organization", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	return toOrganization(org), nil
+}
+
+// ListOrganizations Lists the organizations of the caller with its role in each
+func (s *serverAPI) ListOrganizations(
+	ctx context.Context, _ *authv1.ListOrganizationsRequest,
+) (*authv1.ListOrganizationsResponse, error) {
+	const op = "auth.ListOrganizations"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	orgs, err := s.octl.ListOrganizations(ctx, caller)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list organizations", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	resp := &authv1.ListOrganizationsResponse{Organizations: make([]*authv1.Organization, 0, len(orgs))}
+	for _, org := range orgs {
+		resp.Organizations = append(resp.Organizations, toOrganization(org))
+	}
+
+	return resp, nil
+}
+
+// ListMembers Lists the members of an organization the caller is a member of
+func (s *serverAPI) ListMembers(
+	ctx context.Context, req *authv1.ListMembersRequest,
+) (*authv1.ListMembersResponse, error) {
+	const op = "auth.ListMembers"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if req.GetOrgId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+
+	members, err := s.octl.ListMembers(ctx, caller, req.GetOrgId())
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list members", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	resp := &authv1.ListMembersResponse{Members: make([]*authv1.Member, 0, len(members))}
+	for _, member := range members {
+		resp.Members = append(resp.Members, toMember(member))
+	}
+
+	return resp, nil
+}
+
+// InviteMember Invites an existing owner to the organization with a role
+func (s *serverAPI) InviteMember(
+	ctx context.Context, req *authv1.InviteMemberRequest,
+) (*authv1.Response, error) {
+	const op = "auth.InviteMember"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if req.GetOrgId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+	if req.GetLogin() == "" && req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: login or email is required", op))
+	}
+
+	role := req.GetRole()
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	if !models.ValidOrgRole(role) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: unknown role %s", op, role))
+	}
+
+	err := s.octl.InviteMember(ctx, caller, models.Membership{
+		OrgId:      req.GetOrgId(),
+		Role:       role,
+		ExternalId: req.GetExternalId(),
+	}, models.OwnerKey{Login: req.GetLogin(), Email: req.GetEmail()})
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to invite member", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	return &authv1.Response{Message: "invitation sent"}, nil
+}
+
+// AcceptInvitation Makes the caller a member of the organization it is invited to
+func (s *serverAPI) AcceptInvitation(
+	ctx context.Context, req *authv1.AcceptInvitationRequest,
+) (*authv1.Organization, error) {
+	const op = "auth.AcceptInvitation"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if req.GetOrgId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+
+	org, err := s.octl.AcceptInvitation(ctx, caller, req.GetOrgId())
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to accept invitation", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	return toOrganization(org), nil
+}
+
+// CreateMember Creates an account of an organization with scoped accounts
+func (s *serverAPI) CreateMember(
+	ctx context.Context, req *authv1.CreateMemberRequest,
+) (*authv1.Member, error) {
+	const op = "auth.CreateMember"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if req.GetOrgId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+
+	o := models.Owner{}
+	if err := o.SetEmail(req.GetEmail()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set email %v", op, err))
+	}
+	if err := o.SetLogin(req.GetLogin()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
+	}
+	if err := o.SetPassword(req.GetPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set password %v", op, err))
+	}
+
+	role := req.GetRole()
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	if !models.ValidOrgRole(role) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: unknown role %s", op, role))
+	}
+
+	member, err := s.octl.CreateMember(ctx, caller, models.Membership{
+		OrgId:      req.GetOrgId(),
+		Role:       role,
+		ExternalId: req.GetExternalId(),
+	}, o)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to create member", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	return toMember(member), nil
+}
+
+// RemoveMember Removes a member from the organization, members may remove themselves
+func (s *serverAPI) RemoveMember(
+	ctx context.Context, req *authv1.RemoveMemberRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RemoveMember"
+
+	caller, errC := callerFrom(ctx)
+	if errC != nil {
+		return nil, errC
+	}
+
+	if req.GetOrgId() <= 0 || req.GetOwnerId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org or owner id", op))
+	}
+
+	if err := s.octl.RemoveMember(ctx, caller, req.GetOrgId(), req.GetOwnerId()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to remove member", sl.Err(err))
+
+		return nil, orgError(err)
+	}
+
+	return &authv1.Response{Message: "member removed"}, nil
+}
+
+func callerFrom(ctx context.Context) (models.Caller, error) {
+	caller, ok := grpcctx.Caller(ctx)
+	if !ok {
+		return models.Caller{}, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	return caller, nil
+}
+
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, orgCtl.ErrOrganizationNotFound):
+		return status.Error(codes.NotFound, "organization not found")
+	case errors.Is(err, orgCtl.ErrOwnerNotFound):
+		return status.Error(codes.NotFound, "owner not found")
+	case errors.Is(err, orgCtl.ErrMemberNotFound):
+		return status.Error(codes.NotFound, "member not found")
+	case errors.Is(err, orgCtl.ErrInvitationNotFound):
+		return status.Error(codes.NotFound, "invitation not found")
+	case errors.Is(err, orgCtl.ErrOwnerExists):
+		return status.Error(codes.AlreadyExists, "owner already exists in organization")
+	case errors.Is(err, orgCtl.ErrOrganizationExists):
+		return status.Error(codes.AlreadyExists, "organization already exists")
+	case errors.Is(err, orgCtl.ErrMemberExists):
+		return status.Error(codes.AlreadyExists, "member already exists")
+	case errors.Is(err, orgCtl.ErrExternalIdExists):
+		return status.Error(codes.AlreadyExists, "external id already used in organization")
+	case errors.Is(err, orgCtl.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, orgCtl.ErrNotScoped):
+		return status.Error(codes.FailedPrecondition, "organization has no scoped accounts")
+	case errors.Is(err, orgCtl.ErrLastOwner):
+		return status.Error(codes.FailedPrecondition, "organization must keep an owner")
+	}
+	return status.Error(codes.Internal, "internal error")
+}
+
+func toOrganization(org models.Organization) *authv1.Organization {
+	return &authv1.Organization{
+		Id: org.Id, Name: org.Name, Slug: org.Slug, Role: org.Role, CreatedAt: org.CreatedAt.Unix(),
+		Pending: org.Pending, ScopedAccounts: org.ScopedAccounts,
+	}
+}
+
+func toMember(member models.Membership) *authv1.Member {
+	return &authv1.Member{
+		OwnerId: member.OwnerId, Login: member.Login, Email: member.Email, Role: member.Role,
+		ExternalId: member.ExternalId, JoinedAt: member.JoinedAt.Unix(),
+	}
+}
@@ -5,26 +5,97 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
 	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+	"github.com/viacheslavek/grpcauth/auth/internal/grpc/authz"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/fieldmask"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/grpcctx"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
 	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
 	"github.com/viacheslavek/grpcauth/auth/internal/services/ownerCtl"
 	"github.com/viacheslavek/grpcauth/auth/internal/storage"
 )
 
+// Reasons of the ErrorInfo details of rejected passwords
+const (
+	PasswordPolicyReason   = "PASSWORD_POLICY"
+	PasswordBreachedReason = "PASSWORD_BREACHED"
+	PasswordReusedReason   = "PASSWORD_REUSED"
+
+	errorDomain = "grpcauth"
+)
+
+// ownerSecretsField The Owner field only callers allowed to read secrets get
+const ownerSecretsField = "secrets"
+
+// ownerUpdateFields The paths of an update mask in the order they are set,
+// the password is checked against the login and the email set before it
+var ownerUpdateFields = []string{
+	models.OwnerFieldEmail, models.OwnerFieldLogin, models.OwnerFieldDisplayName, models.OwnerFieldPassword,
+}
+
+// Trigrams need 3 characters to match, longer queries only slow the search down
+const (
+	minSearchLen = 3
+	maxSearchLen = 128
+)
+
 type OwnerCtl interface {
 	CreateOwner(ctx context.Context, owner models.Owner) error
-	UpdateOwner(ctx context.Context, owner models.Owner) error
+	UpdateOwner(ctx context.Context, owner models.Owner, fields []string) error
 	DeleteOwner(ctx context.Context, owner models.Owner) error
 	GetOwner(ctx context.Context, owner models.Owner) (models.Owner, error)
+	ListOwners(ctx context.Context, q models.OwnerListQuery, pageToken string) ([]models.Owner, string, error)
+	SearchOwners(ctx context.Context, caller models.Caller, q models.OwnerSearch) ([]models.OwnerMatch, error)
+
+	LoginOwner(
+		ctx context.Context, owner models.Owner, appId int32, orgId int64, client models.ClientInfo,
+	) (models.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error)
+	VerifyMFA(ctx context.Context, mfaToken string, code string, client models.ClientInfo) (models.Tokens, error)
+
+	IntrospectToken(ctx context.Context, token string, appId int32) (jwt.Claims, error)
+	RevokeToken(ctx context.Context, token string, tokenTypeHint string) error
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+
+	GetJWKS(ctx context.Context) jwt.JWKS
+
+	ListSessions(ctx context.Context, accessToken string) ([]models.Session, string, error)
+	RevokeSession(ctx context.Context, accessToken string, sessionId string) error
+	RevokeAllSessions(ctx context.Context, accessToken string, keepCurrent bool) error
+
+	EnrollTOTP(ctx context.Context, accessToken string) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, accessToken string, code string) error
+	DisableTOTP(ctx context.Context, accessToken string, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, accessToken string, code string) ([]string, error)
+
+	SendVerificationEmail(ctx context.Context, login string, orgId int64) error
+	VerifyEmail(ctx context.Context, token string) error
+
+	RequestPasswordReset(ctx context.Context, key models.OwnerKey) error
+	ResetPassword(ctx context.Context, token string, password string) error
+	ChangePassword(ctx context.Context, accessToken string, currentPassword string, newPassword string) error
 
-	LoginOwner(ctx context.Context, owner models.Owner, appId int) (token string, err error)
+	GetPasswordPolicy(ctx context.Context) validator.PasswordPolicy
+
+	UnlockOwner(ctx context.Context, owner models.Owner) error
+
+	authz.Authenticator
+	AssignRole(ctx context.Context, owner models.Owner, role string) error
+	RevokeRole(ctx context.Context, owner models.Owner, role string) error
+	ListRoles(ctx context.Context, owner models.Owner) ([]models.Role, error)
 }
 
 type serverAPI struct {
@@ -51,7 +122,7 @@ func (s *serverAPI) CreateOwner(
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
 	}
 	if err := o.SetPassword(req.GetPassword()); err != nil {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set password %v", op, err))
+		return nil, passwordError(fmt.Sprintf("%s: failed set password %v", op, err), err)
 	}
 
 	if err := s.octl.CreateOwner(ctx, o); err != nil {
@@ -69,7 +140,7 @@ func (s *serverAPI) CreateOwner(
 	return &authv1.Response{Message: "Success create owner"}, nil
 }
 
-// UpdateOwner Updates the user's login, email, or password in the table by ID
+// UpdateOwner Updates the fields of the user named by the update mask in the table by ID
 func (s *serverAPI) UpdateOwner(
 	ctx context.Context, req *authv1.UpdateOwnerRequest,
 ) (*authv1.Response, error) {
@@ -80,17 +151,60 @@ func (s *serverAPI) UpdateOwner(
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, err))
 	}
 
-	if err := o.SetEmail(req.GetEmail()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set email %v", op, err))
+	values := map[string]string{
+		models.OwnerFieldEmail:       req.GetEmail(),
+		models.OwnerFieldLogin:       req.GetLogin(),
+		models.OwnerFieldPassword:    req.GetPassword(),
+		models.OwnerFieldDisplayName: req.GetDisplayName(),
 	}
-	if err := o.SetLogin(req.GetLogin()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
+
+	paths := req.GetUpdateMask().GetPaths()
+	if req.GetUpdateMask() == nil {
+		// Requests without a mask change the fields they set
+		for _, field := range ownerUpdateFields {
+			if values[field] != "" {
+				paths = append(paths, field)
+			}
+		}
 	}
-	if err := o.SetPassword(req.GetPassword()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set password %v", op, err))
+	for _, path := range paths {
+		if _, ok := values[path]; !ok {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: field %q can not be updated", op, path))
+		}
 	}
 
-	if err := s.octl.UpdateOwner(ctx, o); err != nil {
+	// Owners updating themselves change the password with ChangePassword,
+	// which asks for the current one, so a stolen access token is not enough
+	if slices.Contains(paths, models.OwnerFieldPassword) {
+		if caller, _ := grpcctx.Caller(ctx); !caller.HasPermission(models.PermOwnersWrite) {
+			return nil, status.Error(codes.PermissionDenied, fmt.Sprintf(
+				"%s: the password of oneself is changed with ChangePassword", op,
+			))
+		}
+	}
+
+	fields := make([]string, 0, len(paths))
+	for _, field := range ownerUpdateFields {
+		if !slices.Contains(paths, field) {
+			continue
+		}
+
+		if err := setOwnerField(&o, field, values[field]); err != nil {
+			if errors.Is(err, validator.ErrEmptyParameter) {
+				return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %s can not be cleared", op, field))
+			}
+			if field == models.OwnerFieldPassword {
+				return nil, passwordError(fmt.Sprintf("%s: failed set password %v", op, err), err)
+			}
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set %s %v", op, field, err))
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: nothing to update", op))
+	}
+
+	if err := s.octl.UpdateOwner(ctx, o, fields); err != nil {
 		s.lg.With(
 			slog.String("op", op),
 		).Error("failed to update owner", sl.Err(err))
@@ -98,6 +212,15 @@ func (s *serverAPI) UpdateOwner(
 		if errors.Is(err, ownerCtl.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid id")
 		}
+		if errors.Is(err, ownerCtl.ErrNothingToUpdate) {
+			return nil, status.Error(codes.InvalidArgument, "nothing to update")
+		}
+		if errors.Is(err, storage.ErrOwnerExists) {
+			return nil, status.Error(codes.AlreadyExists, "login or email is taken")
+		}
+		if errors.Is(err, ownerCtl.ErrWeakPassword) || errors.Is(err, ownerCtl.ErrPasswordReused) {
+			return nil, passwordError("password does not follow the policy", err)
+		}
 
 		return nil, status.Error(codes.Internal, "internal error")
 	}
@@ -105,6 +228,24 @@ func (s *serverAPI) UpdateOwner(
 	return &authv1.Response{Message: "Success update owner"}, nil
 }
 
+// setOwnerField Sets the field of the update, an empty display name is left empty to clear it
+func setOwnerField(o *models.Owner, field string, value string) error {
+	switch field {
+	case models.OwnerFieldEmail:
+		return o.SetEmail(value)
+	case models.OwnerFieldLogin:
+		return o.SetLogin(value)
+	case models.OwnerFieldPassword:
+		return o.SetPassword(value)
+	case models.OwnerFieldDisplayName:
+		if value == "" {
+			return nil
+		}
+		return o.SetDisplayName(value)
+	}
+	return fmt.Errorf("unknown owner field %s", field)
+}
+
 // DeleteOwner Deletes a user from the table by ID or login
 func (s *serverAPI) DeleteOwner(
 	ctx context.Context, req *authv1.DeleteOwnerRequest,
@@ -144,7 +285,7 @@ func (s *serverAPI) DeleteOwner(
 func (s *serverAPI) GetOwner(
 	ctx context.Context, req *authv1.GetOwnerRequest,
 ) (*authv1.Owner, error) {
-	const op = "papa auth.GetOwner"
+	const op = "auth.GetOwner"
 
 	o := models.Owner{}
 	errIdVal := o.SetId(req.GetId())
@@ -160,6 +301,18 @@ func (s *serverAPI) GetOwner(
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, errLoginVal))
 	}
 
+	paths := req.GetReadMask().GetPaths()
+	if err := fieldmask.Validate(&authv1.Owner{}, paths); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %v", op, err))
+	}
+
+	withSecrets := fieldmask.Names(paths, ownerSecretsField)
+	if withSecrets {
+		if caller, _ := grpcctx.Caller(ctx); !caller.HasPermission(models.PermOwnersReadSecrets) {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+	}
+
 	owner, err := s.octl.GetOwner(ctx, o)
 	if err != nil {
 		s.lg.With(
@@ -173,9 +326,139 @@ func (s *serverAPI) GetOwner(
 		return nil, status.Error(codes.Internal, "internal error")
 	}
 
+	resp := &authv1.Owner{
+		Id: owner.Id(), Email: owner.Email(), Login: owner.Login(),
+		EmailVerified: owner.EmailVerified(), MfaEnabled: owner.MFAEnabled(),
+		RecoveryCodesRemaining: int32(owner.RecoveryCodesLeft()),
+		CreatedAt:              owner.CreatedAt().Unix(), Locked: owner.Locked(),
+		DisplayName: owner.DisplayName(),
+	}
+	if withSecrets {
+		resp.Secrets = &authv1.OwnerSecrets{
+			PasswordHash: string(owner.PassHash()), PepperVersion: int32(owner.PepperVersion()),
+		}
+	}
+
+	// The mask is valid, so pruning can not fail
+	_ = fieldmask.Prune(resp, paths)
+
+	return resp, nil
+}
+
+var ownerSortFields = map[authv1.OwnerSortField]string{
+	authv1.OwnerSortField_OWNER_SORT_ID:         models.OwnerSortId,
+	authv1.OwnerSortField_OWNER_SORT_CREATED_AT: models.OwnerSortCreatedAt,
+	authv1.OwnerSortField_OWNER_SORT_LOGIN:      models.OwnerSortLogin,
+	authv1.OwnerSortField_OWNER_SORT_EMAIL:      models.OwnerSortEmail,
+}
+
+// ListOwners Lists owners page by page with filters and a sort order
+func (s *serverAPI) ListOwners(
+	ctx context.Context, req *authv1.ListOwnersRequest,
+) (*authv1.ListOwnersResponse, error) {
+	const op = "auth.ListOwners"
+
+	sort, ok := ownerSortFields[req.GetSortBy()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: unknown sort field %v", op, req.GetSortBy()))
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: negative page size", op))
+	}
+
+	paths := req.GetReadMask().GetPaths()
+	if err := fieldmask.Validate(&authv1.Owner{}, paths); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %v", op, err))
+	}
+	if fieldmask.Names(paths, ownerSecretsField) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: secrets are not listed", op))
+	}
+
+	filter := models.OwnerFilter{
+		LoginPrefix: req.GetLoginPrefix(),
+		EmailPrefix: req.GetEmailPrefix(),
+		Verified:    req.EmailVerified,
+		Locked:      req.Locked,
+	}
+	if req.GetCreatedAfter() != 0 {
+		filter.CreatedAfter = time.Unix(req.GetCreatedAfter(), 0)
+	}
+	if req.GetCreatedBefore() != 0 {
+		filter.CreatedBefore = time.Unix(req.GetCreatedBefore(), 0)
+	}
+
+	owners, next, err := s.octl.ListOwners(ctx, models.OwnerListQuery{
+		Filter: filter,
+		Sort:   sort,
+		Desc:   req.GetDescending(),
+		Limit:  int(req.GetPageSize()),
+	}, req.GetPageToken())
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list owners", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	resp := &authv1.ListOwnersResponse{Owners: make([]*authv1.Owner, 0, len(owners)), NextPageToken: next}
+	for _, owner := range owners {
+		listed := toListedOwner(owner)
+		_ = fieldmask.Prune(listed, paths)
+		resp.Owners = append(resp.Owners, listed)
+	}
+
+	return resp, nil
+}
+
+// SearchOwners Finds owners by a partial or misspelled login or email with match scores
+func (s *serverAPI) SearchOwners(
+	ctx context.Context, req *authv1.SearchOwnersRequest,
+) (*authv1.SearchOwnersResponse, error) {
+	const op = "auth.SearchOwners"
+
+	caller, ok := grpcctx.Caller(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	query := strings.TrimSpace(req.GetQuery())
+	if n := utf8.RuneCountInString(query); n < minSearchLen || n > maxSearchLen {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf(
+			"%s: query must be %d to %d characters long", op, minSearchLen, maxSearchLen,
+		))
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: negative limit", op))
+	}
+
+	matches, err := s.octl.SearchOwners(ctx, caller, models.OwnerSearch{Text: query, Limit: int(req.GetLimit())})
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to search owners", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	resp := &authv1.SearchOwnersResponse{Matches: make([]*authv1.OwnerMatch, 0, len(matches))}
+	for _, match := range matches {
+		resp.Matches = append(resp.Matches, &authv1.OwnerMatch{Owner: toListedOwner(match.Owner), Score: match.Score})
+	}
+
+	return resp, nil
+}
+
+// toListedOwner The owner view of listings, it has no secrets and no second factor state
+func toListedOwner(owner models.Owner) *authv1.Owner {
 	return &authv1.Owner{
-		Id: owner.Id(), Email: owner.Email(), Login: owner.Login(), PasswordHash: string(owner.PassHash()),
-	}, nil
+		Id: owner.Id(), Email: owner.Email(), Login: owner.Login(), EmailVerified: owner.EmailVerified(),
+		CreatedAt: owner.CreatedAt().Unix(), Locked: owner.Locked(), DisplayName: owner.DisplayName(),
+	}
 }
 
 // LoginOwner Issues a JWT token by login and password
@@ -191,11 +474,20 @@ func (s *serverAPI) LoginOwner(
 	if err := o.SetLogin(req.GetLogin()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
 	}
-	if err := o.SetPassword(req.GetPassword()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set password %v", op, err))
+	// The password is not checked against the policy, a rejected one would reveal the policy
+	// before the credentials are checked
+	if err := o.SetLoginPassword(req.GetPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty password", op))
+	}
+	a := models.App{}
+	if err := a.SetId(req.GetAppId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set app id %v", op, err))
+	}
+	if req.GetOrgId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
 	}
 
-	token, err := s.octl.LoginOwner(ctx, o, int(req.GetAppId()))
+	tokens, err := s.octl.LoginOwner(ctx, o, a.Id(), req.GetOrgId(), grpcctx.ClientInfo(ctx))
 	if err != nil {
 		s.lg.With(
 			slog.String("op", op),
@@ -204,9 +496,784 @@ func (s *serverAPI) LoginOwner(
 		if errors.Is(err, ownerCtl.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
+		if errors.Is(err, ownerCtl.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+		if errors.Is(err, ownerCtl.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email not verified")
+		}
+		if errors.Is(err, ownerCtl.ErrNotOrgMember) {
+			return nil, status.Error(codes.PermissionDenied, "not a member of the organization")
+		}
+		var retry *ownerCtl.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, loginBlockedError(retry)
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	if tokens.MFAToken != "" {
+		return &authv1.LoginResponse{MfaRequired: true, MfaToken: tokens.MFAToken}, nil
+	}
+
+	return &authv1.LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// RefreshToken Rotates the refresh token and issues a new access token,
+// a replayed refresh token revokes all tokens issued from the same login
+func (s *serverAPI) RefreshToken(
+	ctx context.Context, req *authv1.RefreshTokenRequest,
+) (*authv1.LoginResponse, error) {
+	const op = "auth.RefreshToken"
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty refresh token", op))
+	}
+
+	tokens, err := s.octl.RefreshToken(ctx, req.GetRefreshToken(), grpcctx.ClientInfo(ctx))
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to refresh token", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) || errors.Is(err, ownerCtl.ErrRefreshTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// VerifyMFA Completes a login of an owner with a second factor and issues tokens
+func (s *serverAPI) VerifyMFA(
+	ctx context.Context, req *authv1.VerifyMFARequest,
+) (*authv1.LoginResponse, error) {
+	const op = "auth.VerifyMFA"
+	if req.GetMfaToken() == "" || req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty parameters", op))
+	}
+
+	tokens, err := s.octl.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode(), grpcctx.ClientInfo(ctx))
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to verify mfa", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid mfa token")
+		}
+		if errors.Is(err, ownerCtl.ErrInvalidMFACode) {
+			return nil, status.Error(codes.Unauthenticated, "invalid mfa code")
+		}
+		if errors.Is(err, ownerCtl.ErrMFADisabled) {
+			return nil, status.Error(codes.FailedPrecondition, "mfa is not configured")
+		}
+		var retry *ownerCtl.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, loginBlockedError(retry)
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// IntrospectToken Reports whether the token is active and returns its claims
+func (s *serverAPI) IntrospectToken(
+	ctx context.Context, req *authv1.IntrospectTokenRequest,
+) (*authv1.IntrospectTokenResponse, error) {
+	const op = "auth.IntrospectToken"
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty token", op))
+	}
+	if req.GetAppId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid app id", op))
+	}
+
+	claims, err := s.octl.IntrospectToken(ctx, req.GetToken(), req.GetAppId())
+	if err != nil {
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return &authv1.IntrospectTokenResponse{Active: false}, nil
+		}
+
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to introspect token", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.IntrospectTokenResponse{
+		Active:  true,
+		Uid:     claims.Uid,
+		Login:   claims.Login,
+		Email:   claims.Email,
+		AppId:   claims.AppId,
+		Exp:     claims.ExpiresAt.Unix(),
+		OrgId:   claims.OrgId,
+		OrgRole: claims.OrgRole,
+	}, nil
+}
+
+// RevokeToken Revokes an access token or the refresh token family, an invalid token is ignored
+func (s *serverAPI) RevokeToken(
+	ctx context.Context, req *authv1.RevokeTokenRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RevokeToken"
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty token", op))
+	}
+	switch req.GetTokenTypeHint() {
+	case "", ownerCtl.TokenTypeAccess, ownerCtl.TokenTypeRefresh:
+	default:
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: unsupported token type hint", op))
+	}
+
+	if err := s.octl.RevokeToken(ctx, req.GetToken(), req.GetTokenTypeHint()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to revoke token", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success revoke token"}, nil
+}
+
+// Logout Revokes the access token and the refresh token family of the session
+func (s *serverAPI) Logout(
+	ctx context.Context, req *authv1.LogoutRequest,
+) (*authv1.Response, error) {
+	const op = "auth.Logout"
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty token", op))
+	}
+
+	if err := s.octl.Logout(ctx, req.GetToken(), req.GetRefreshToken()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to logout", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success logout"}, nil
+}
+
+// GetJWKS Returns the public keys verifying issued tokens
+func (s *serverAPI) GetJWKS(
+	ctx context.Context, _ *authv1.GetJWKSRequest,
+) (*authv1.JWKS, error) {
+	jwks := s.octl.GetJWKS(ctx)
+
+	res := &authv1.JWKS{Keys: make([]*authv1.JWK, 0, len(jwks.Keys))}
+	for _, key := range jwks.Keys {
+		res.Keys = append(res.Keys, &authv1.JWK{
+			Kty: key.Kty, Kid: key.Kid, Use: key.Use, Alg: key.Alg,
+			N: key.N, E: key.E, Crv: key.Crv, X: key.X, Y: key.Y,
+		})
+	}
+
+	return res, nil
+}
+
+// ListSessions Returns the active sessions of the caller
+func (s *serverAPI) ListSessions(
+	ctx context.Context, _ *authv1.ListSessionsRequest,
+) (*authv1.ListSessionsResponse, error) {
+	const op = "auth.ListSessions"
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	sessions, currentId, err := s.octl.ListSessions(ctx, token)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list sessions", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	res := &authv1.ListSessionsResponse{Sessions: make([]*authv1.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, &authv1.Session{
+			Id:        session.Id,
+			AppId:     session.AppId,
+			Ip:        session.Client.IP,
+			UserAgent: session.Client.UserAgent,
+			CreatedAt: session.CreatedAt.Unix(),
+			LastSeen:  session.LastSeen.Unix(),
+			Current:   session.Id == currentId,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSession Revokes a session of the caller with its tokens
+func (s *serverAPI) RevokeSession(
+	ctx context.Context, req *authv1.RevokeSessionRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RevokeSession"
+	if req.GetSessionId() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty session id", op))
+	}
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	if err := s.octl.RevokeSession(ctx, token, req.GetSessionId()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to revoke session", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		if errors.Is(err, ownerCtl.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success revoke session"}, nil
+}
+
+// RevokeAllSessions Revokes every session of the caller, optionally except the current one
+func (s *serverAPI) RevokeAllSessions(
+	ctx context.Context, req *authv1.RevokeAllSessionsRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RevokeAllSessions"
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	if err := s.octl.RevokeAllSessions(ctx, token, req.GetKeepCurrent()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to revoke all sessions", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success revoke all sessions"}, nil
+}
+
+// EnrollTOTP Generates a TOTP secret of the caller, it has to be confirmed by ConfirmTOTP
+func (s *serverAPI) EnrollTOTP(
+	ctx context.Context, _ *authv1.EnrollTOTPRequest,
+) (*authv1.EnrollTOTPResponse, error) {
+	const op = "auth.EnrollTOTP"
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	enrollment, err := s.octl.EnrollTOTP(ctx, token)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to enroll totp", sl.Err(err))
+
+		return nil, totpError(err)
+	}
+
+	return &authv1.EnrollTOTPResponse{
+		Secret:        enrollment.Secret,
+		Uri:           enrollment.URI,
+		RecoveryCodes: enrollment.RecoveryCodes,
+	}, nil
+}
+
+// ConfirmTOTP Enables the TOTP secret of the caller with a code generated by it
+func (s *serverAPI) ConfirmTOTP(
+	ctx context.Context, req *authv1.ConfirmTOTPRequest,
+) (*authv1.Response, error) {
+	const op = "auth.ConfirmTOTP"
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty code", op))
+	}
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	if err := s.octl.ConfirmTOTP(ctx, token, req.GetCode()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to confirm totp", sl.Err(err))
+
+		return nil, totpError(err)
+	}
+
+	return &authv1.Response{Message: "Success confirm totp"}, nil
+}
+
+// DisableTOTP Removes the TOTP secret of the caller
+func (s *serverAPI) DisableTOTP(
+	ctx context.Context, req *authv1.DisableTOTPRequest,
+) (*authv1.Response, error) {
+	const op = "auth.DisableTOTP"
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	if err := s.octl.DisableTOTP(ctx, token, req.GetCode()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to disable totp", sl.Err(err))
+
+		return nil, totpError(err)
+	}
+
+	return &authv1.Response{Message: "Success disable totp"}, nil
+}
+
+// RegenerateRecoveryCodes Replaces the recovery codes of the caller
+func (s *serverAPI) RegenerateRecoveryCodes(
+	ctx context.Context, req *authv1.RegenerateRecoveryCodesRequest,
+) (*authv1.RecoveryCodesResponse, error) {
+	const op = "auth.RegenerateRecoveryCodes"
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty code", op))
+	}
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	recoveryCodes, err := s.octl.RegenerateRecoveryCodes(ctx, token, req.GetCode())
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to regenerate recovery codes", sl.Err(err))
+
+		return nil, totpError(err)
+	}
+
+	return &authv1.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// SendVerificationEmail Sends a verification link to the email of the owner
+func (s *serverAPI) SendVerificationEmail(
+	ctx context.Context, req *authv1.SendVerificationEmailRequest,
+) (*authv1.Response, error) {
+	const op = "auth.SendVerificationEmail"
+
+	o := models.Owner{}
+	if err := o.SetLogin(req.GetLogin()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
+	}
+	if req.GetOrgId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+
+	if err := s.octl.SendVerificationEmail(ctx, o.Login(), req.GetOrgId()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to send verification email", sl.Err(err))
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Verification email is sent if the owner exists"}, nil
+}
+
+// VerifyEmail Marks the email of the owner as verified by the token from the email
+func (s *serverAPI) VerifyEmail(
+	ctx context.Context, req *authv1.VerifyEmailRequest,
+) (*authv1.Response, error) {
+	const op = "auth.VerifyEmail"
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty token", op))
+	}
+
+	if err := s.octl.VerifyEmail(ctx, req.GetToken()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to verify email", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success verify email"}, nil
+}
+
+// RequestPasswordReset Emails a password reset link to the owner with the login or email
+func (s *serverAPI) RequestPasswordReset(
+	ctx context.Context, req *authv1.RequestPasswordResetRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RequestPasswordReset"
+
+	o := models.Owner{}
+	errLoginVal := o.SetLogin(req.GetLogin())
+	errEmailVal := o.SetEmail(req.GetEmail())
+
+	if errors.Is(errLoginVal, validator.ErrEmptyParameter) && errors.Is(errEmailVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, "empty all reset parameters")
+	}
+	if errLoginVal != nil && !errors.Is(errLoginVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, errLoginVal))
+	}
+	if errEmailVal != nil && !errors.Is(errEmailVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set email %v", op, errEmailVal))
+	}
+	if req.GetOrgId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: invalid org id", op))
+	}
+
+	if err := s.octl.RequestPasswordReset(ctx, models.OwnerKey{
+		Login: o.Login(), Email: o.Email(), OrgId: req.GetOrgId(),
+	}); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to request password reset", sl.Err(err))
 
 		return nil, status.Error(codes.Internal, "internal error")
 	}
 
-	return &authv1.LoginResponse{Token: token}, nil
+	return &authv1.Response{Message: "Password reset email is sent if the owner exists"}, nil
+}
+
+// ResetPassword Sets a new password by the token from the password reset email
+func (s *serverAPI) ResetPassword(
+	ctx context.Context, req *authv1.ResetPasswordRequest,
+) (*authv1.Response, error) {
+	const op = "auth.ResetPassword"
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty token", op))
+	}
+
+	o := models.Owner{}
+	if err := o.SetPassword(req.GetPassword()); err != nil {
+		return nil, passwordError(fmt.Sprintf("%s: failed set password %v", op, err), err)
+	}
+
+	if err := s.octl.ResetPassword(ctx, req.GetToken(), o.Password()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to reset password", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+		if errors.Is(err, ownerCtl.ErrWeakPassword) || errors.Is(err, ownerCtl.ErrPasswordReused) {
+			return nil, passwordError("password does not follow the policy", err)
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success reset password"}, nil
+}
+
+// ChangePassword Sets a new password of the caller after checking the current one
+func (s *serverAPI) ChangePassword(
+	ctx context.Context, req *authv1.ChangePasswordRequest,
+) (*authv1.Response, error) {
+	const op = "auth.ChangePassword"
+	if req.GetCurrentPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty current password", op))
+	}
+
+	o := models.Owner{}
+	if err := o.SetPassword(req.GetNewPassword()); err != nil {
+		return nil, passwordError(fmt.Sprintf("%s: failed set new password %v", op, err), err)
+	}
+
+	token, ok := grpcctx.BearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("%s: missing bearer token", op))
+	}
+
+	if err := s.octl.ChangePassword(ctx, token, req.GetCurrentPassword(), o.Password()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to change password", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		if errors.Is(err, ownerCtl.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid current password")
+		}
+		if errors.Is(err, ownerCtl.ErrWeakPassword) || errors.Is(err, ownerCtl.ErrPasswordReused) {
+			return nil, passwordError("password does not follow the policy", err)
+		}
+		var retry *ownerCtl.RetryAfterError
+		if errors.As(err, &retry) {
+			return nil, loginBlockedError(retry)
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success change password"}, nil
+}
+
+// GetPasswordPolicy Returns the rules new passwords are checked with
+func (s *serverAPI) GetPasswordPolicy(
+	ctx context.Context, _ *authv1.GetPasswordPolicyRequest,
+) (*authv1.PasswordPolicy, error) {
+	policy := s.octl.GetPasswordPolicy(ctx)
+
+	return &authv1.PasswordPolicy{
+		MinLength:           int32(policy.MinLength),
+		MaxLength:           int32(policy.MaxLength),
+		RequireLetter:       policy.RequireLetter,
+		RequireLower:        policy.RequireLower,
+		RequireUpper:        policy.RequireUpper,
+		RequireDigit:        policy.RequireDigit,
+		RequireSymbol:       policy.RequireSymbol,
+		MaxRepeated:         int32(policy.MaxRepeated),
+		MinEntropy:          policy.MinEntropy,
+		ForbidPersonal:      policy.ForbidPersonal,
+		ForbiddenSubstrings: policy.ForbiddenSubstrings,
+		RejectBreached:      policy.Breached != nil,
+	}, nil
+}
+
+// UnlockOwner Lifts the lockout and the backoff of the owner logins by ID or login
+func (s *serverAPI) UnlockOwner(
+	ctx context.Context, req *authv1.UnlockOwnerRequest,
+) (*authv1.Response, error) {
+	const op = "auth.UnlockOwner"
+
+	o := models.Owner{}
+	errIdVal := o.SetId(req.GetId())
+	errLoginVal := o.SetLogin(req.GetLogin())
+
+	if errors.Is(errIdVal, validator.ErrEmptyParameter) && errors.Is(errLoginVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, "empty all unlock parameters")
+	}
+	if errIdVal != nil && !errors.Is(errIdVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, errIdVal))
+	}
+	if errLoginVal != nil && !errors.Is(errLoginVal, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, errLoginVal))
+	}
+
+	if err := s.octl.UnlockOwner(ctx, o); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to unlock owner", sl.Err(err))
+
+		if errors.Is(err, ownerCtl.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid login or id")
+		}
+
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &authv1.Response{Message: "Success unlock owner"}, nil
+}
+
+// AssignRole Assigns a role to the owner by ID or login
+func (s *serverAPI) AssignRole(
+	ctx context.Context, req *authv1.AssignRoleRequest,
+) (*authv1.Response, error) {
+	const op = "auth.AssignRole"
+
+	o, err := roleOwner(op, req.GetId(), req.GetLogin())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRole() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty role", op))
+	}
+
+	if err = s.octl.AssignRole(ctx, o, req.GetRole()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to assign role", sl.Err(err))
+
+		return nil, roleError(err)
+	}
+
+	return &authv1.Response{Message: "Success assign role"}, nil
+}
+
+// RevokeRole Removes a role from the owner by ID or login
+func (s *serverAPI) RevokeRole(
+	ctx context.Context, req *authv1.RevokeRoleRequest,
+) (*authv1.Response, error) {
+	const op = "auth.RevokeRole"
+
+	o, err := roleOwner(op, req.GetId(), req.GetLogin())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRole() == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty role", op))
+	}
+
+	if err = s.octl.RevokeRole(ctx, o, req.GetRole()); err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to revoke role", sl.Err(err))
+
+		return nil, roleError(err)
+	}
+
+	return &authv1.Response{Message: "Success revoke role"}, nil
+}
+
+// ListRoles Returns the roles of the owner by ID or login, or every role without them
+func (s *serverAPI) ListRoles(
+	ctx context.Context, req *authv1.ListRolesRequest,
+) (*authv1.ListRolesResponse, error) {
+	const op = "auth.ListRoles"
+
+	o := models.Owner{}
+	if err := o.SetId(req.GetId()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, err))
+	}
+	if err := o.SetLogin(req.GetLogin()); err != nil && !errors.Is(err, validator.ErrEmptyParameter) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, err))
+	}
+
+	roles, err := s.octl.ListRoles(ctx, o)
+	if err != nil {
+		s.lg.With(
+			slog.String("op", op),
+		).Error("failed to list roles", sl.Err(err))
+
+		return nil, roleError(err)
+	}
+
+	resp := &authv1.ListRolesResponse{Roles: make([]*authv1.Role, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, &authv1.Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return resp, nil
+}
+
+// roleOwner Returns the owner with the id or login roles are managed for, one of them is required
+func roleOwner(op string, id int64, login string) (models.Owner, error) {
+	o := models.Owner{}
+	errIdVal := o.SetId(id)
+	errLoginVal := o.SetLogin(login)
+
+	if errors.Is(errIdVal, validator.ErrEmptyParameter) && errors.Is(errLoginVal, validator.ErrEmptyParameter) {
+		return models.Owner{}, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: empty id and login", op))
+	}
+	if errIdVal != nil && !errors.Is(errIdVal, validator.ErrEmptyParameter) {
+		return models.Owner{}, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set id %v", op, errIdVal))
+	}
+	if errLoginVal != nil && !errors.Is(errLoginVal, validator.ErrEmptyParameter) {
+		return models.Owner{}, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: failed set login %v", op, errLoginVal))
+	}
+
+	return o, nil
+}
+
+// roleError Maps the errors of the role endpoints to statuses
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, ownerCtl.ErrInvalidCredentials):
+		return status.Error(codes.NotFound, "owner not found")
+	case errors.Is(err, ownerCtl.ErrRoleNotFound):
+		return status.Error(codes.InvalidArgument, "unknown role")
+	case errors.Is(err, ownerCtl.ErrRoleNotAssigned):
+		return status.Error(codes.FailedPrecondition, "role not assigned")
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// loginBlockedError A locked owner is PermissionDenied and a backoff is ResourceExhausted,
+// both carry the wait in RetryInfo details
+func loginBlockedError(retry *ownerCtl.RetryAfterError) error {
+	code, msg := codes.ResourceExhausted, "too many failed logins"
+	if errors.Is(retry, ownerCtl.ErrOwnerLocked) {
+		code, msg = codes.PermissionDenied, "owner is locked"
+	}
+
+	st, err := status.New(code, fmt.Sprintf("%s, retry after %s", msg, retry.RetryAfter)).WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retry.RetryAfter)},
+	)
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
+
+// passwordError Returns InvalidArgument with the reason of the rejection in ErrorInfo details,
+// breached and recently used passwords are reported with their own messages and reasons
+func passwordError(msg string, err error) error {
+	reason := PasswordPolicyReason
+	switch {
+	case errors.Is(err, validator.ErrBreachedPassword):
+		msg, reason = "password is known from breaches or too common", PasswordBreachedReason
+	case errors.Is(err, ownerCtl.ErrPasswordReused):
+		msg, reason = "password was used recently", PasswordReusedReason
+	}
+
+	st, errD := status.New(codes.InvalidArgument, msg).WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain},
+	)
+	if errD != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+
+	return st.Err()
+}
+
+// totpError Maps the errors of the TOTP management endpoints to statuses
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, ownerCtl.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, ownerCtl.ErrInvalidMFACode):
+		return status.Error(codes.InvalidArgument, "invalid code")
+	case errors.Is(err, ownerCtl.ErrTOTPEnabled):
+		return status.Error(codes.FailedPrecondition, "totp already enabled")
+	case errors.Is(err, ownerCtl.ErrTOTPNotEnabled):
+		return status.Error(codes.FailedPrecondition, "totp not enabled")
+	case errors.Is(err, ownerCtl.ErrMFADisabled):
+		return status.Error(codes.FailedPrecondition, "mfa is not configured")
+	}
+	var retry *ownerCtl.RetryAfterError
+	if errors.As(err, &retry) {
+		return loginBlockedError(retry)
+	}
+	return status.Error(codes.Internal, "internal error")
 }
@@ -0,0 +1,82 @@
+package actiontoken
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Purposes of the tokens, a token is accepted only for the purpose it is signed for
+const (
+	PurposeVerifyEmail = "verify_email"
+)
+
+var ErrInvalidToken = errors.New("invalid action token")
+
+// Claims The owner a token is signed for and the email it is bound to,
+// a token stops verifying once the owner changes the email
+type Claims struct {
+	Uid   int64
+	Email string
+}
+
+type tokenClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// Signer Signs short lived tokens sent to owners by email (HS256)
+type Signer struct {
+	key []byte
+}
+
+func New(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+func (s *Signer) Sign(purpose string, claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		Email: claims.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(claims.Uid, 10),
+			Audience:  jwt.ClaimStrings{purpose},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign action token %w", err)
+	}
+
+	return signed, nil
+}
+
+// Verify Checks the signature, the expiry and the purpose
+func (s *Signer) Verify(purpose string, token string) (Claims, error) {
+	var claims tokenClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(purpose),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	uid, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+
+	return Claims{Uid: uid, Email: claims.Email}, nil
+}
@@ -0,0 +1,46 @@
+package actiontoken
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	signer := New([]byte("test-key"))
+	claims := Claims{Uid: 42, Email: "owner@example.com"}
+
+	valid, _ := signer.Sign(PurposeVerifyEmail, claims, time.Hour)
+	expired, _ := signer.Sign(PurposeVerifyEmail, claims, -time.Hour)
+	otherPurpose, _ := signer.Sign("reset_password", claims, time.Hour)
+	otherKey, _ := New([]byte("other-key")).Sign(PurposeVerifyEmail, claims, time.Hour)
+
+	tests := []struct {
+		name        string
+		token       string
+		expectError bool
+	}{
+		{"valid", valid, false},
+		{"expired", expired, true},
+		{"other purpose", otherPurpose, true},
+		{"other key", otherKey, true},
+		{"garbage", "garbage", true},
+	}
+
+	for _, test := range tests {
+		got, err := signer.Verify(PurposeVerifyEmail, test.token)
+		if test.expectError {
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: did not expect error, but got: %v", test.name, err)
+			continue
+		}
+		if got != claims {
+			t.Errorf("%s: expected claims %+v, got %+v", test.name, claims, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package breached
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sha1HexLen    = 40
+	hibpPrefixLen = 5
+	minBits       = 64
+)
+
+var ErrInvalidList = errors.New("invalid breached password list")
+
+// Filter A bloom filter of SHA-1 digests of breached passwords. It answers
+// without false negatives and with false positives at the rate it is built for
+type Filter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// NewFilter Sizes the filter for n passwords with the given false positive rate, the rate is in (0, 1)
+func NewFilter(n int, falsePositiveRate float64) *Filter {
+	m := uint64(minBits)
+	if n > 0 {
+		m = max(m, uint64(math.Ceil(-float64(n)*math.Log(falsePositiveRate)/(math.Ln2*math.Ln2))))
+	}
+
+	k := uint64(1)
+	if n > 0 {
+		k = max(k, uint64(math.Round(float64(m)/float64(n)*math.Ln2)))
+	}
+
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Load Builds a filter from a list file and a directory of HIBP range files, either may be empty.
+// A list line is a plain password or a hex SHA-1 digest optionally followed by ":<count>".
+// A range file is named by the first 5 hex digits of the digests and holds "<suffix>:<count>" lines
+func Load(listPath string, hibpDir string, falsePositiveRate float64) (*Filter, error) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false positive rate %v is not in (0, 1)", falsePositiveRate)
+	}
+
+	sources, err := listSources(listPath, hibpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, src := range sources {
+		errR := src.read(func([sha1.Size]byte) { n++ })
+		if errR != nil {
+			return nil, errR
+		}
+	}
+
+	f := NewFilter(n, falsePositiveRate)
+	for _, src := range sources {
+		if errR := src.read(f.add); errR != nil {
+			return nil, errR
+		}
+	}
+
+	return f, nil
+}
+
+// Contains Reports whether the password is likely in the list
+func (f *Filter) Contains(password string) bool {
+	return f.contains(sha1.Sum([]byte(password)))
+}
+
+func (f *Filter) add(digest [sha1.Size]byte) {
+	h1, h2 := f.hashes(digest)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (f *Filter) contains(digest [sha1.Size]byte) bool {
+	h1, h2 := f.hashes(digest)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes The digest is already uniform, its halves are the two hashes of double hashing
+func (f *Filter) hashes(digest [sha1.Size]byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(digest[:8]), binary.LittleEndian.Uint64(digest[8:16]) | 1
+}
+
+// source A file with one entry per line, parse returns false for lines to skip
+type source struct {
+	path  string
+	parse func(line string) ([sha1.Size]byte, bool, error)
+}
+
+func listSources(listPath string, hibpDir string) ([]source, error) {
+	sources := make([]source, 0)
+	if listPath != "" {
+		sources = append(sources, source{path: listPath, parse: parseListLine})
+	}
+
+	if hibpDir != "" {
+		entries, err := os.ReadDir(hibpDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hibp directory %w", err)
+		}
+
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			if entry.IsDir() || len(name) != hibpPrefixLen || !isHex(name) {
+				continue
+			}
+
+			prefix := strings.ToUpper(name)
+			sources = append(sources, source{
+				path: filepath.Join(hibpDir, entry.Name()),
+				parse: func(line string) ([sha1.Size]byte, bool, error) {
+					return parseRangeLine(prefix, line)
+				},
+			})
+		}
+	}
+
+	return sources, nil
+}
+
+func (s source) read(add func([sha1.Size]byte)) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password list %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		digest, ok, errP := s.parse(strings.TrimSpace(scanner.Text()))
+		if errP != nil {
+			return fmt.Errorf("%w: %s line %d: %w", ErrInvalidList, s.path, line, errP)
+		}
+		if ok {
+			add(digest)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password list %w", err)
+	}
+
+	return nil
+}
+
+func parseListLine(line string) ([sha1.Size]byte, bool, error) {
+	if line == "" {
+		return [sha1.Size]byte{}, false, nil
+	}
+
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) == sha1HexLen && isHex(hash) {
+		var digest [sha1.Size]byte
+		_, err := hex.Decode(digest[:], []byte(hash))
+		return digest, err == nil, err
+	}
+
+	return sha1.Sum([]byte(line)), true, nil
+}
+
+// parseRangeLine Skips the zero count lines HIBP pads responses with
+func parseRangeLine(prefix string, line string) ([sha1.Size]byte, bool, error) {
+	if line == "" {
+		return [sha1.Size]byte{}, false, nil
+	}
+
+	suffix, count, ok := strings.Cut(line, ":")
+	if !ok || len(suffix) != sha1HexLen-hibpPrefixLen {
+		return [sha1.Size]byte{}, false, errors.New("expected <suffix>:<count>")
+	}
+	if strings.TrimSpace(count) == "0" {
+		return [sha1.Size]byte{}, false, nil
+	}
+
+	var digest [sha1.Size]byte
+	if _, err := hex.Decode(digest[:], []byte(prefix+suffix)); err != nil {
+		return [sha1.Size]byte{}, false, err
+	}
+
+	return digest, true, nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
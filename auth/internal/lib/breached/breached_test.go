@@ -0,0 +1,103 @@
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestLoad_List(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "common.txt")
+
+	content := "123456\npassword\n\nqwerty123\n" + sha1Hex("letmein1") + ":42\n"
+	if err := os.WriteFile(list, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+
+	f, err := Load(list, "", 0.001)
+	if err != nil {
+		t.Fatalf("failed to load list: %v", err)
+	}
+
+	for _, password := range []string{"123456", "password", "qwerty123", "letmein1"} {
+		if !f.Contains(password) {
+			t.Errorf("expected %q to be in the list", password)
+		}
+	}
+	if f.Contains("Correct-Horse-Battery-Staple7") {
+		t.Errorf("did not expect a strong password in the list")
+	}
+}
+
+func TestLoad_HIBPDir(t *testing.T) {
+	dir := t.TempDir()
+
+	digest := sha1Hex("hunter2")
+	padded := strings.Repeat("0", 35)
+	content := digest[5:] + ":17\r\n" + padded + ":0\n"
+	if err := os.WriteFile(filepath.Join(dir, digest[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write range file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a range"), 0o600); err != nil {
+		t.Fatalf("failed to write readme: %v", err)
+	}
+
+	f, err := Load("", dir, 0.001)
+	if err != nil {
+		t.Fatalf("failed to load hibp directory: %v", err)
+	}
+
+	if !f.Contains("hunter2") {
+		t.Errorf("expected the password of the range file to be in the list")
+	}
+}
+
+func TestLoad_InvalidRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ABCDE.txt"), []byte("garbage\n"), 0o600); err != nil {
+		t.Fatalf("failed to write range file: %v", err)
+	}
+
+	if _, err := Load("", dir, 0.001); !errors.Is(err, ErrInvalidList) {
+		t.Errorf("expected ErrInvalidList, got: %v", err)
+	}
+}
+
+func TestLoad_InvalidFalsePositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, -0.1, 1, 2} {
+		if _, err := Load("", "", rate); err == nil {
+			t.Errorf("expected error for false positive rate %v", rate)
+		}
+	}
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	const n = 10000
+
+	f := NewFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		f.add(sha1.Sum([]byte(fmt.Sprintf("in-%d", i))))
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if f.Contains(fmt.Sprintf("out-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / n; rate > 0.03 {
+		t.Errorf("expected a false positive rate near 0.01, got %f", rate)
+	}
+}
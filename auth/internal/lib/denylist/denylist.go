@@ -0,0 +1,119 @@
+package denylist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// Store Persists revoked token ids so that every replica sees them
+type Store interface {
+	SaveRevokedToken(ctx context.Context, jti string, expiresAt time.Time) error
+	GetRevokedToken(ctx context.Context, jti string) (expiresAt time.Time, err error)
+	DeleteExpiredRevokedTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Denylist Keeps revoked token ids until the tokens expire.
+// Revoked ids are cached in memory, a cache miss falls back to the store
+// because the token could have been revoked by another replica
+type Denylist struct {
+	log   *slog.Logger
+	store Store
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func New(log *slog.Logger, store Store) *Denylist {
+	return &Denylist{
+		log:     log,
+		store:   store,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (d *Denylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	if err := d.store.SaveRevokedToken(ctx, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token %w", err)
+	}
+
+	d.remember(jti, expiresAt)
+
+	return nil
+}
+
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	d.mu.RLock()
+	_, ok := d.revoked[jti]
+	d.mu.RUnlock()
+	if ok {
+		return true, nil
+	}
+
+	expiresAt, err := d.store.GetRevokedToken(ctx, jti)
+	if err != nil {
+		if errors.Is(err, storage.ErrRevokedTokenNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check revoked token %w", err)
+	}
+
+	d.remember(jti, expiresAt)
+
+	return true, nil
+}
+
+// Run Prunes expired ids from memory and from the store until ctx is done
+func (d *Denylist) Run(ctx context.Context, interval time.Duration) {
+	const op = "denylist.Run"
+
+	log := d.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			d.prune(now)
+
+			pruned, err := d.store.DeleteExpiredRevokedTokens(ctx, now)
+			if err != nil {
+				log.Error("failed to prune revoked tokens", sl.Err(err))
+				continue
+			}
+			log.Debug("revoked tokens pruned", slog.Int64("count", pruned))
+		}
+	}
+}
+
+func (d *Denylist) remember(jti string, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.revoked[jti] = expiresAt
+}
+
+func (d *Denylist) prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for jti, expiresAt := range d.revoked {
+		if !expiresAt.After(now) {
+			delete(d.revoked, jti)
+		}
+	}
+}
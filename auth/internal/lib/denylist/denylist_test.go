@@ -0,0 +1,78 @@
+package denylist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/handlers/slogdiscard"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+type memoryStore struct {
+	tokens map[string]time.Time
+	gets   int
+}
+
+func (m *memoryStore) SaveRevokedToken(_ context.Context, jti string, expiresAt time.Time) error {
+	m.tokens[jti] = expiresAt
+	return nil
+}
+
+func (m *memoryStore) GetRevokedToken(_ context.Context, jti string) (time.Time, error) {
+	m.gets++
+	expiresAt, ok := m.tokens[jti]
+	if !ok {
+		return time.Time{}, storage.ErrRevokedTokenNotFound
+	}
+	return expiresAt, nil
+}
+
+func (m *memoryStore) DeleteExpiredRevokedTokens(_ context.Context, before time.Time) (int64, error) {
+	var n int64
+	for jti, expiresAt := range m.tokens {
+		if !expiresAt.After(before) {
+			delete(m.tokens, jti)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestDenylist(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{tokens: make(map[string]time.Time)}
+	d := New(slogdiscard.NewDiscardLogger(), store)
+
+	if err := d.Revoke(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Did not expect error on revoke, but got: %v", err)
+	}
+	if err := d.Revoke(ctx, "expired", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Did not expect error on revoke, but got: %v", err)
+	}
+
+	if revoked, _ := d.IsRevoked(ctx, "live"); !revoked {
+		t.Errorf("Expected live token to be revoked")
+	}
+	if store.gets != 0 {
+		t.Errorf("Expected cached token to be checked without the store")
+	}
+	if revoked, _ := d.IsRevoked(ctx, "expired"); revoked {
+		t.Errorf("Expected already expired token not to be stored")
+	}
+
+	// Revoked by another replica
+	store.tokens["remote"] = time.Now().Add(time.Hour)
+	if revoked, _ := d.IsRevoked(ctx, "remote"); !revoked {
+		t.Errorf("Expected token revoked in the store to be revoked")
+	}
+
+	d.remember("stale", time.Now().Add(-time.Minute))
+	d.prune(time.Now())
+	if _, ok := d.revoked["stale"]; ok {
+		t.Errorf("Expected expired token to be pruned from memory")
+	}
+	if _, ok := d.revoked["live"]; !ok {
+		t.Errorf("Did not expect live token to be pruned from memory")
+	}
+}
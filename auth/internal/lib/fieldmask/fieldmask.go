@@ -0,0 +1,86 @@
+package fieldmask
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var ErrInvalidPath = errors.New("invalid field mask path")
+
+// Validate Checks that every path names a field of the message
+func Validate(msg proto.Message, paths []string) error {
+	if _, err := fieldmaskpb.New(msg, paths...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPath, err)
+	}
+	return nil
+}
+
+// Prune Clears the fields of the message the paths do not name, a path naming
+// a message field keeps all of it. No paths keep the whole message
+func Prune(msg proto.Message, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	if err := Validate(msg, paths); err != nil {
+		return err
+	}
+
+	root := node{}
+	for _, path := range paths {
+		root.add(strings.Split(path, "."))
+	}
+	root.prune(msg.ProtoReflect())
+
+	return nil
+}
+
+// Names Reports whether a path names the field or a field within it
+func Names(paths []string, field string) bool {
+	for _, path := range paths {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// node The kept fields of a message by name, an empty node keeps every field
+type node map[protoreflect.Name]node
+
+func (n node) add(names []string) {
+	child, ok := n[protoreflect.Name(names[0])]
+	if ok && len(child) == 0 {
+		// The whole field is already kept
+		return
+	}
+	if !ok || len(names) == 1 {
+		child = node{}
+		n[protoreflect.Name(names[0])] = child
+	}
+	if len(names) > 1 {
+		child.add(names[1:])
+	}
+}
+
+func (n node) prune(m protoreflect.Message) {
+	var cleared []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := n[fd.Name()]
+		switch {
+		case !ok:
+			cleared = append(cleared, fd)
+		case len(child) > 0 && fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			child.prune(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range cleared {
+		m.Clear(fd)
+	}
+}
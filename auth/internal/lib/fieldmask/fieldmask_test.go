@@ -0,0 +1,66 @@
+package fieldmask
+
+import (
+	"errors"
+	"testing"
+
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/protobuf/proto"
+)
+
+func testOwner() *authv1.Owner {
+	return &authv1.Owner{
+		Id: 42, Login: "owner42", Email: "owner42@example.com", EmailVerified: true,
+		Secrets: &authv1.OwnerSecrets{PasswordHash: "hash", PepperVersion: 2},
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  *authv1.Owner
+	}{
+		{"no paths", nil, testOwner()},
+		{"top level", []string{"id", "login"}, &authv1.Owner{Id: 42, Login: "owner42"}},
+		{"whole message field", []string{"secrets"}, &authv1.Owner{
+			Secrets: &authv1.OwnerSecrets{PasswordHash: "hash", PepperVersion: 2},
+		}},
+		{"nested field", []string{"id", "secrets.pepper_version"}, &authv1.Owner{
+			Id: 42, Secrets: &authv1.OwnerSecrets{PepperVersion: 2},
+		}},
+		{"whole field wins", []string{"secrets.pepper_version", "secrets"}, &authv1.Owner{
+			Secrets: &authv1.OwnerSecrets{PasswordHash: "hash", PepperVersion: 2},
+		}},
+	}
+
+	for _, test := range tests {
+		got := testOwner()
+		if err := Prune(got, test.paths); err != nil {
+			t.Errorf("%s: did not expect error, but got: %v", test.name, err)
+			continue
+		}
+		if !proto.Equal(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPrune_InvalidPath(t *testing.T) {
+	for _, path := range []string{"password_hash", "secrets.unknown", "id.value"} {
+		if err := Prune(testOwner(), []string{path}); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("%s: expected ErrInvalidPath, got: %v", path, err)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	paths := []string{"id", "secrets.password_hash"}
+
+	if !Names(paths, "secrets") {
+		t.Errorf("expected a nested path to name its message field")
+	}
+	if Names(paths, "secret") || Names(paths, "login") {
+		t.Errorf("did not expect paths to name other fields")
+	}
+}
@@ -0,0 +1,68 @@
+package grpcctx
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+const (
+	authorizationHeader = "authorization"
+	userAgentHeader     = "user-agent"
+	bearerPrefix        = "bearer "
+)
+
+// BearerToken Returns the access token from the "authorization: Bearer <token>" metadata
+func BearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get(authorizationHeader) {
+		if len(value) > len(bearerPrefix) && strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(value[len(bearerPrefix):]), true
+		}
+	}
+
+	return "", false
+}
+
+// ClientInfo Returns the peer IP and the user agent of the request.
+// Forwarding headers are ignored because they are set by the client
+func ClientInfo(ctx context.Context) models.ClientInfo {
+	var client models.ClientInfo
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IP); err == nil {
+			client.IP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if agents := md.Get(userAgentHeader); len(agents) > 0 {
+			client.UserAgent = agents[0]
+		}
+	}
+
+	return client
+}
+
+type callerKey struct{}
+
+// WithCaller Returns the context carrying the authenticated caller of the request
+func WithCaller(ctx context.Context, caller models.Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// Caller Returns the caller set by WithCaller, requests of unauthorized RPCs have none
+func Caller(ctx context.Context) (models.Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(models.Caller)
+	return caller, ok
+}
@@ -0,0 +1,61 @@
+package grpcctx
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header      string
+		token       string
+		expectFound bool
+	}{
+		{"Bearer abc.def.ghi", "abc.def.ghi", true},
+		{"bearer abc", "abc", true},
+		{"Basic dXNlcjpwYXNz", "", false},
+		{"Bearer ", "", false},
+	}
+
+	for _, test := range tests {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", test.header))
+		token, ok := BearerToken(ctx)
+		if ok != test.expectFound || token != test.token {
+			t.Errorf("For header %q expected (%q, %v), got (%q, %v)", test.header, test.token, test.expectFound, token, ok)
+		}
+	}
+
+	if _, ok := BearerToken(context.Background()); ok {
+		t.Errorf("Did not expect a token without metadata")
+	}
+}
+
+func TestClientInfo(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 51234},
+	})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("user-agent", "grpc-go/1.64.0"))
+
+	client := ClientInfo(ctx)
+	if client.IP != "192.0.2.10" || client.UserAgent != "grpc-go/1.64.0" {
+		t.Errorf("Unexpected client info %+v", client)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	if _, ok := Caller(context.Background()); ok {
+		t.Errorf("Did not expect a caller in an empty context")
+	}
+
+	ctx := WithCaller(context.Background(), models.Caller{Id: 7, Login: "owner7"})
+	caller, ok := Caller(ctx)
+	if !ok || caller.Id != 7 || caller.Login != "owner7" {
+		t.Errorf("Expected the set caller, got (%+v, %v)", caller, ok)
+	}
+}
@@ -1,7 +1,12 @@
 package jwt
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,21 +14,196 @@ import (
 	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
 )
 
-func NewToken(owner models.Owner, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+var (
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+const jtiLen = 16
+
+var validMethods = []string{
+	jwt.SigningMethodHS256.Alg(),
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// Claims The owner claims carried by tokens issued by NewToken
+type Claims struct {
+	Uid   int64  `json:"uid"`
+	Email string `json:"email"`
+	Login string `json:"login"`
+	AppId int32  `json:"app_id"`
+	Sid   string `json:"sid"`
+	// Roles The roles of the owner when the token is issued
+	Roles []string `json:"roles,omitempty"`
+	// OrgId The organization the owner logged in to with its role in it, zero for none
+	OrgId   int64  `json:"org_id,omitempty"`
+	OrgRole string `json:"org_role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// AppProvider Returns the app a token claims to be issued for
+type AppProvider func(appId int32) (models.App, error)
+
+// KeySet Provides the keys signing and verifying tokens
+type KeySet interface {
+	// SigningKey Reports false when tokens are signed with app secrets
+	SigningKey() (Key, bool)
+	VerificationKey(kid string) (Key, bool)
+	PublicKeys() []Key
+}
+
+// StaticKeys Keys loaded once from configuration, the first key signs tokens
+type StaticKeys []Key
+
+func (s StaticKeys) SigningKey() (Key, bool) {
+	if len(s) == 0 {
+		return Key{}, false
+	}
+	return s[0], true
+}
+
+func (s StaticKeys) VerificationKey(kid string) (Key, bool) {
+	idx := slices.IndexFunc(s, func(key Key) bool { return key.Id == kid })
+	if idx < 0 {
+		return Key{}, false
+	}
+	return s[idx], true
+}
+
+func (s StaticKeys) PublicKeys() []Key {
+	return s
+}
+
+// Manager Issues and verifies owner tokens with the keys of the key set.
+// Without a signing key tokens are signed with the secret of the app they are
+// issued for (HS256). Once a signing key is configured such tokens are rejected,
+// every app holds its secret and could sign them for any owner
+type Manager struct {
+	keys KeySet
+	// hs256Until Keeps HS256 tokens verifying next to a signing key until then,
+	// for the tokens issued before the key to expire
+	hs256Until time.Time
+}
+
+// NewManager hs256Until is the end of the migration to signing keys, zero rejects HS256
+// as soon as a signing key is configured
+func NewManager(keys KeySet, hs256Until time.Time) *Manager {
+	return &Manager{keys: keys, hs256Until: hs256Until}
+}
+
+// NewToken Issues a token for the owner and the app it is issued for within the session,
+// org is the membership of the organization the session is logged in to, if any
+func (m *Manager) NewToken(
+	owner models.Owner, app models.App, org models.Membership, sessionId string, duration time.Duration,
+) (string, error) {
+	jti, errJ := newJTI()
+	if errJ != nil {
+		return "", errJ
+	}
+
+	now := time.Now()
 
-	claims := token.Claims.(jwt.MapClaims)
+	claims := jwt.MapClaims{}
 	claims["uid"] = owner.Id()
 	claims["email"] = owner.Email()
 	claims["login"] = owner.Login()
-	claims["exp"] = time.Now().Add(duration).Unix()
+	claims["app_id"] = app.Id()
+	claims["aud"] = Audience(app.Id())
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(duration).Unix()
+	claims["jti"] = jti
+	claims["sid"] = sessionId
+	if roles := owner.Roles(); len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	if org.OrgId != 0 {
+		claims["org_id"] = org.OrgId
+		claims["org_role"] = org.Role
+	}
+
+	signingKey, ok := m.keys.SigningKey()
+	if !ok {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(app.Secret()))
+	}
 
-	secret := os.Getenv("JWT_SECRET")
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.Id
 
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := token.SignedString(signingKey.private)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// ParseToken Verifies the signature, the expiry and the audience.
+// HS256 tokens are verified with the secret of the app from the token claims
+// while there is no signing key, the others with the public key named by the kid header
+func (m *Manager) ParseToken(tokenString string, appProvider AppProvider) (Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		app, err := appProvider(claims.AppId)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method == jwt.SigningMethodHS256 {
+			if _, ok := m.keys.SigningKey(); ok && !time.Now().Before(m.hs256Until) {
+				return nil, fmt.Errorf("%w %s next to signing keys", ErrUnsupportedAlgorithm, token.Method.Alg())
+			}
+			return []byte(app.Secret()), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.VerificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if key.Method != token.Method {
+			return nil, fmt.Errorf("%w %s", ErrKeyMismatch, token.Method.Alg())
+		}
+
+		return key.Public(), nil
+	},
+		jwt.WithValidMethods(validMethods),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if !slices.Contains(claims.Audience, Audience(claims.AppId)) {
+		return Claims{}, fmt.Errorf("%w: audience does not match app %d", ErrInvalidToken, claims.AppId)
+	}
+
+	return claims, nil
+}
+
+// JWKS Returns the public keys verifying tokens, empty when tokens are signed by app secrets
+func (m *Manager) JWKS() JWKS {
+	keys := m.keys.PublicKeys()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+
+	return jwks
+}
+
+// Audience Returns the audience claim value for the app
+func Audience(appId int32) string {
+	return "app:" + strconv.Itoa(int(appId))
+}
+
+// newJTI Returns a random token identifier used to revoke the token before it expires
+func newJTI() (string, error) {
+	buf := make([]byte, jtiLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate jti %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,173 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+func testOwner() models.Owner {
+	var o models.Owner
+	_ = o.SetId(42)
+	_ = o.SetLogin("owner42")
+	_ = o.SetEmail("owner42@example.com")
+	o.SetRoles([]string{"viewer"})
+	return o
+}
+
+func testApp(id int32, secret string) models.App {
+	var a models.App
+	_ = a.SetId(id)
+	_ = a.SetName("app")
+	a.SetSecret(secret)
+	return a
+}
+
+func TestParseToken(t *testing.T) {
+	app := testApp(1, "first-secret")
+	other := testApp(2, "second-secret")
+
+	apps := func(appId int32) (models.App, error) {
+		switch appId {
+		case app.Id():
+			return app, nil
+		case other.Id():
+			return other, nil
+		}
+		return models.App{}, errors.New("unknown app")
+	}
+
+	m := NewManager(StaticKeys(nil), time.Time{})
+	org := models.Membership{OrgId: 7, Role: models.OrgRoleAdmin}
+	valid, _ := m.NewToken(testOwner(), app, org, "session", time.Hour)
+	expired, _ := m.NewToken(testOwner(), app, models.Membership{}, "session", -time.Hour)
+	forged, _ := m.NewToken(testOwner(), testApp(1, "forged-secret"), models.Membership{}, "session", time.Hour)
+	unknown, _ := m.NewToken(testOwner(), testApp(3, "third-secret"), models.Membership{}, "session", time.Hour)
+
+	tests := []struct {
+		name        string
+		token       string
+		expectError bool
+	}{
+		{"valid", valid, false},
+		{"expired", expired, true},
+		{"wrong secret", forged, true},
+		{"unknown app", unknown, true},
+		{"garbage", "not.a.token", true},
+	}
+
+	for _, test := range tests {
+		claims, err := m.ParseToken(test.token, apps)
+		if test.expectError {
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: did not expect error, but got: %v", test.name, err)
+			continue
+		}
+		if claims.Uid != 42 || claims.Login != "owner42" || claims.AppId != app.Id() || claims.ID == "" ||
+			claims.IssuedAt == nil || !slices.Equal(claims.Roles, []string{"viewer"}) ||
+			claims.OrgId != org.OrgId || claims.OrgRole != org.Role {
+			t.Errorf("%s: unexpected claims %+v", test.name, claims)
+		}
+	}
+}
+
+func TestManager_AsymmetricKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		algorithm string
+		private   crypto.Signer
+		kty       string
+	}{
+		{"RS256", rsaKey, "RSA"},
+		{"ES256", ecKey, "EC"},
+		{"EdDSA", edKey, "OKP"},
+	}
+
+	app := testApp(1, "secret")
+	apps := func(int32) (models.App, error) { return app, nil }
+
+	for _, test := range tests {
+		key, err := NewKey("kid-"+test.algorithm, test.algorithm, test.private)
+		if err != nil {
+			t.Fatalf("%s: did not expect error on key, but got: %v", test.algorithm, err)
+		}
+
+		m := NewManager(StaticKeys{key}, time.Time{})
+		token, err := m.NewToken(testOwner(), app, models.Membership{}, "session", time.Hour)
+		if err != nil {
+			t.Fatalf("%s: did not expect error on sign, but got: %v", test.algorithm, err)
+		}
+
+		if _, err = m.ParseToken(token, apps); err != nil {
+			t.Errorf("%s: did not expect error on parse, but got: %v", test.algorithm, err)
+		}
+
+		// A verifier without the key must reject the token
+		if _, err = NewManager(StaticKeys(nil), time.Time{}).ParseToken(token, apps); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken without the key, got: %v", test.algorithm, err)
+		}
+
+		// An app holding its secret must not sign tokens once keys are configured
+		hs256, _ := NewManager(StaticKeys(nil), time.Time{}).NewToken(
+			testOwner(), app, models.Membership{}, "session", time.Hour,
+		)
+		if _, err = m.ParseToken(hs256, apps); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken for HS256 next to the key, got: %v", test.algorithm, err)
+		}
+		migrating := NewManager(StaticKeys{key}, time.Now().Add(time.Hour))
+		if _, err = migrating.ParseToken(hs256, apps); err != nil {
+			t.Errorf("%s: did not expect error for HS256 while migrating, but got: %v", test.algorithm, err)
+		}
+
+		jwks := m.JWKS()
+		if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != test.kty || jwks.Keys[0].Kid != key.Id {
+			t.Errorf("%s: unexpected jwks %+v", test.algorithm, jwks)
+		}
+	}
+
+	if _, err := NewKey("mismatch", "ES256", rsaKey); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Expected ErrKeyMismatch for RSA key with ES256, got: %v", err)
+	}
+	if _, err := NewKey("unsupported", "HS512", rsaKey); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm, got: %v", err)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	key, err := LoadKey("main", "ES256", path)
+	if err != nil {
+		t.Fatalf("Did not expect error on load, but got: %v", err)
+	}
+	if key.Id != "main" || key.Method.Alg() != "ES256" {
+		t.Errorf("Unexpected key %+v", key)
+	}
+}
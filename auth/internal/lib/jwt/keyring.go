@@ -0,0 +1,340 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+)
+
+const (
+	kidLen = 8
+
+	// reloadTimeout Bounds a reload caused by a token signed with an unknown key
+	reloadTimeout = 3 * time.Second
+	// minReloadInterval Keeps tokens with forged kid headers from hammering the store
+	minReloadInterval = 5 * time.Second
+)
+
+// ErrSigningKeyConflict Is returned by a KeyStore when another replica rotated the key at the same time
+var ErrSigningKeyConflict = errors.New("signing key was rotated concurrently")
+
+// KeyStore Persists signing keys so that every replica signs with the same active key
+type KeyStore interface {
+	ListSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	RotateSigningKey(ctx context.Context, key models.SigningKey) error
+	EncryptSigningKey(ctx context.Context, id string, sealed []byte) error
+	RetireSigningKeys(ctx context.Context, retiringBefore time.Time) (int64, error)
+}
+
+// SecretBox Encrypts the private keys kept in the store, a read of the store does not leak them
+type SecretBox interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// RingKey A key of the ring with its lifecycle state
+type RingKey struct {
+	Key
+	State      string
+	CreatedAt  time.Time
+	RetiringAt *time.Time
+}
+
+// KeyRing Signs tokens with the single active key and verifies them with the active
+// and retiring keys. A rotated key keeps retiring for retireAfter, which must outlive
+// the tokens it signed, so rotation does not invalidate outstanding tokens
+type KeyRing struct {
+	log         *slog.Logger
+	store       KeyStore
+	box         SecretBox
+	algorithm   string
+	retireAfter time.Duration
+	rotateEvery time.Duration
+
+	mu         sync.RWMutex
+	keys       []RingKey
+	reloadedAt time.Time
+}
+
+func NewKeyRing(
+	log *slog.Logger,
+	store KeyStore,
+	box SecretBox,
+	algorithm string,
+	retireAfter time.Duration,
+	rotateEvery time.Duration,
+) (*KeyRing, error) {
+	if _, err := signingMethod(algorithm); err != nil {
+		return nil, err
+	}
+	if box == nil {
+		return nil, errors.New("key ring needs a secret box to encrypt private keys")
+	}
+
+	return &KeyRing{
+		log:         log,
+		store:       store,
+		box:         box,
+		algorithm:   algorithm,
+		retireAfter: retireAfter,
+		rotateEvery: rotateEvery,
+	}, nil
+}
+
+// Load Encrypts the keys stored before encryption, reads the keys from the store
+// and creates the first active key when there is none
+func (r *KeyRing) Load(ctx context.Context) error {
+	if err := r.encryptStored(ctx); err != nil {
+		return err
+	}
+
+	if err := r.reload(ctx); err != nil {
+		return err
+	}
+
+	if _, ok := r.SigningKey(); ok {
+		return nil
+	}
+
+	if _, err := r.Rotate(ctx); err != nil && !errors.Is(err, ErrSigningKeyConflict) {
+		return err
+	}
+
+	return r.reload(ctx)
+}
+
+// Rotate Creates a new active key, the previous active key starts retiring
+func (r *KeyRing) Rotate(ctx context.Context) (RingKey, error) {
+	const op = "keyring.Rotate"
+
+	kid, err := newKid()
+	if err != nil {
+		return RingKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := GenerateKey(kid, r.algorithm)
+	if err != nil {
+		return RingKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	private, err := key.MarshalPEM()
+	if err != nil {
+		return RingKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := r.box.Seal(private)
+	if err != nil {
+		return RingKey{}, fmt.Errorf("%s: failed to encrypt key %w", op, err)
+	}
+
+	if err = r.store.RotateSigningKey(ctx, models.SigningKey{
+		Id:         key.Id,
+		Algorithm:  r.algorithm,
+		PrivateKey: sealed,
+		Encrypted:  true,
+	}); err != nil {
+		return RingKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = r.reload(ctx); err != nil {
+		return RingKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.log.Info("signing key rotated", slog.String("op", op), slog.String("kid", key.Id))
+
+	return RingKey{Key: key, State: models.SigningKeyActive, CreatedAt: time.Now()}, nil
+}
+
+// Keys Returns the active and retiring keys, the active key first
+func (r *KeyRing) Keys() []RingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]RingKey(nil), r.keys...)
+}
+
+func (r *KeyRing) SigningKey() (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.keys) == 0 || r.keys[0].State != models.SigningKeyActive {
+		return Key{}, false
+	}
+	return r.keys[0].Key, true
+}
+
+// VerificationKey Reloads the ring on a miss, another replica could have rotated the key
+func (r *KeyRing) VerificationKey(kid string) (Key, bool) {
+	if key, ok := r.find(kid); ok {
+		return key, true
+	}
+
+	r.mu.RLock()
+	recent := time.Since(r.reloadedAt) < minReloadInterval
+	r.mu.RUnlock()
+	if recent {
+		return Key{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+
+	if err := r.reload(ctx); err != nil {
+		r.log.Error("failed to reload key ring", sl.Err(err))
+		return Key{}, false
+	}
+
+	return r.find(kid)
+}
+
+func (r *KeyRing) PublicKeys() []Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]Key, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key.Key)
+	}
+	return keys
+}
+
+// Run Retires expired keys, rotates the active key every rotateEvery when it is set
+// and reloads keys rotated by other replicas until ctx is done
+func (r *KeyRing) Run(ctx context.Context, interval time.Duration) {
+	const op = "keyring.Run"
+
+	log := r.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.store.RetireSigningKeys(ctx, time.Now().Add(-r.retireAfter)); err != nil {
+				log.Error("failed to retire signing keys", sl.Err(err))
+			}
+
+			if err := r.reload(ctx); err != nil {
+				log.Error("failed to reload key ring", sl.Err(err))
+				continue
+			}
+
+			if r.rotationDue() {
+				if _, err := r.Rotate(ctx); err != nil && !errors.Is(err, ErrSigningKeyConflict) {
+					log.Error("failed to rotate signing key", sl.Err(err))
+				}
+			}
+		}
+	}
+}
+
+func (r *KeyRing) rotationDue() bool {
+	if r.rotateEvery <= 0 {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.keys) == 0 || time.Since(r.keys[0].CreatedAt) >= r.rotateEvery
+}
+
+func (r *KeyRing) find(kid string) (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Id == kid {
+			return key.Key, true
+		}
+	}
+	return Key{}, false
+}
+
+func (r *KeyRing) reload(ctx context.Context) error {
+	stored, err := r.store.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys %w", err)
+	}
+
+	keys := make([]RingKey, 0, len(stored))
+	for _, s := range stored {
+		if s.State == models.SigningKeyRetired {
+			continue
+		}
+
+		private := s.PrivateKey
+		if s.Encrypted {
+			opened, errO := r.box.Open(s.PrivateKey)
+			if errO != nil {
+				return fmt.Errorf("failed to decrypt signing key %s %w", s.Id, errO)
+			}
+			private = opened
+		}
+
+		key, errPK := ParseKey(s.Id, s.Algorithm, private)
+		if errPK != nil {
+			return errPK
+		}
+
+		ringKey := RingKey{Key: key, State: s.State, CreatedAt: s.CreatedAt, RetiringAt: s.RetiringAt}
+		if s.State == models.SigningKeyActive {
+			keys = append([]RingKey{ringKey}, keys...)
+		} else {
+			keys = append(keys, ringKey)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = keys
+	r.reloadedAt = time.Now()
+
+	return nil
+}
+
+// encryptStored Seals the plaintext private keys stored before encryption, retired ones included
+func (r *KeyRing) encryptStored(ctx context.Context) error {
+	stored, err := r.store.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys %w", err)
+	}
+
+	for _, s := range stored {
+		if s.Encrypted {
+			continue
+		}
+
+		sealed, errS := r.box.Seal(s.PrivateKey)
+		if errS != nil {
+			return fmt.Errorf("failed to encrypt signing key %s %w", s.Id, errS)
+		}
+		if errS = r.store.EncryptSigningKey(ctx, s.Id, sealed); errS != nil {
+			return errS
+		}
+
+		r.log.Info("stored signing key encrypted", slog.String("kid", s.Id))
+	}
+
+	return nil
+}
+
+func newKid() (string, error) {
+	buf := make([]byte, kidLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate kid %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
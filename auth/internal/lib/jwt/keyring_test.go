@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/handlers/slogdiscard"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/secretbox"
+)
+
+type memoryKeyStore struct {
+	keys []models.SigningKey
+}
+
+func (m *memoryKeyStore) ListSigningKeys(_ context.Context) ([]models.SigningKey, error) {
+	return append([]models.SigningKey(nil), m.keys...), nil
+}
+
+func (m *memoryKeyStore) RotateSigningKey(_ context.Context, key models.SigningKey) error {
+	now := time.Now()
+	for i := range m.keys {
+		if m.keys[i].State == models.SigningKeyActive {
+			m.keys[i].State = models.SigningKeyRetiring
+			m.keys[i].RetiringAt = &now
+		}
+	}
+	key.State = models.SigningKeyActive
+	key.CreatedAt = now
+	m.keys = append([]models.SigningKey{key}, m.keys...)
+	return nil
+}
+
+func (m *memoryKeyStore) EncryptSigningKey(_ context.Context, id string, sealed []byte) error {
+	for i := range m.keys {
+		if m.keys[i].Id == id && !m.keys[i].Encrypted {
+			m.keys[i].PrivateKey = sealed
+			m.keys[i].Encrypted = true
+		}
+	}
+	return nil
+}
+
+func (m *memoryKeyStore) RetireSigningKeys(_ context.Context, retiringBefore time.Time) (int64, error) {
+	var n int64
+	for i := range m.keys {
+		if m.keys[i].State == models.SigningKeyRetiring && !m.keys[i].RetiringAt.After(retiringBefore) {
+			m.keys[i].State = models.SigningKeyRetired
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryKeyStore{}
+
+	ring, err := NewKeyRing(slogdiscard.NewDiscardLogger(), store, testBox(t), "ES256", time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Did not expect error on key ring, but got: %v", err)
+	}
+	if err = ring.Load(ctx); err != nil {
+		t.Fatalf("Did not expect error on load, but got: %v", err)
+	}
+	if _, ok := ring.SigningKey(); !ok {
+		t.Fatalf("Expected the first active key to be created on load")
+	}
+
+	m := NewManager(ring, time.Time{})
+	app := testApp(1, "secret")
+	apps := func(int32) (models.App, error) { return app, nil }
+
+	oldToken, _ := m.NewToken(testOwner(), app, models.Membership{}, "session", time.Hour)
+
+	if _, err = ring.Rotate(ctx); err != nil {
+		t.Fatalf("Did not expect error on rotate, but got: %v", err)
+	}
+	if len(ring.Keys()) != 2 || ring.Keys()[1].State != models.SigningKeyRetiring {
+		t.Fatalf("Expected active and retiring keys, got %+v", ring.Keys())
+	}
+
+	newToken, _ := m.NewToken(testOwner(), app, models.Membership{}, "session", time.Hour)
+	if _, err = m.ParseToken(newToken, apps); err != nil {
+		t.Errorf("Did not expect error for token of the active key, but got: %v", err)
+	}
+	if _, err = m.ParseToken(oldToken, apps); err != nil {
+		t.Errorf("Did not expect error for token of the retiring key, but got: %v", err)
+	}
+
+	// Retire everything that started retiring until now, as Run does once retireAfter passes
+	if _, err = store.RetireSigningKeys(ctx, time.Now()); err != nil {
+		t.Fatalf("failed to retire keys: %v", err)
+	}
+	if err = ring.reload(ctx); err != nil {
+		t.Fatalf("failed to reload keys: %v", err)
+	}
+	if _, err = m.ParseToken(oldToken, apps); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for token of the retired key, got: %v", err)
+	}
+	if len(m.JWKS().Keys) != 1 {
+		t.Errorf("Expected retired key to leave jwks, got %+v", m.JWKS())
+	}
+}
+
+func TestKeyRing_EncryptsKeys(t *testing.T) {
+	ctx := context.Background()
+
+	plain, err := GenerateKey("plain", "ES256")
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	private, err := plain.MarshalPEM()
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	// A key stored before encryption is sealed on load and keeps verifying
+	store := &memoryKeyStore{keys: []models.SigningKey{{
+		Id: plain.Id, Algorithm: "ES256", PrivateKey: private, State: models.SigningKeyActive, CreatedAt: time.Now(),
+	}}}
+
+	ring, err := NewKeyRing(slogdiscard.NewDiscardLogger(), store, testBox(t), "ES256", time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Did not expect error on key ring, but got: %v", err)
+	}
+	if err = ring.Load(ctx); err != nil {
+		t.Fatalf("Did not expect error on load, but got: %v", err)
+	}
+	if _, err = ring.Rotate(ctx); err != nil {
+		t.Fatalf("Did not expect error on rotate, but got: %v", err)
+	}
+
+	for _, key := range store.keys {
+		if !key.Encrypted || bytes.Contains(key.PrivateKey, []byte("PRIVATE KEY")) {
+			t.Errorf("Expected key %s to be stored encrypted", key.Id)
+		}
+	}
+	if _, ok := ring.VerificationKey(plain.Id); !ok {
+		t.Errorf("Expected the encrypted key stored before to verify tokens")
+	}
+
+	other, err := NewKeyRing(slogdiscard.NewDiscardLogger(), store, otherBox(t), "ES256", time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Did not expect error on key ring, but got: %v", err)
+	}
+	if err = other.Load(ctx); err == nil {
+		t.Errorf("Expected error on load with another secret key")
+	}
+}
+
+func testBox(t *testing.T) *secretbox.Box {
+	box, err := secretbox.New(bytes.Repeat([]byte{1}, secretbox.KeyLen))
+	if err != nil {
+		t.Fatalf("failed to init secret box: %v", err)
+	}
+	return box
+}
+
+func otherBox(t *testing.T) *secretbox.Box {
+	box, err := secretbox.New(bytes.Repeat([]byte{2}, secretbox.KeyLen))
+	if err != nil {
+		t.Fatalf("failed to init secret box: %v", err)
+	}
+	return box
+}
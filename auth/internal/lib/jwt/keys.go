@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const rsaKeyBits = 2048
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrKeyMismatch          = errors.New("key does not match algorithm")
+)
+
+// Key An asymmetric signing key identified by the kid header of the tokens it signs
+type Key struct {
+	Id      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+func NewKey(id string, algorithm string, private crypto.Signer) (Key, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if err = checkKeyType(method, private); err != nil {
+		return Key{}, err
+	}
+
+	return Key{Id: id, Method: method, private: private}, nil
+}
+
+// LoadKey Reads a PKCS #8, PKCS #1 or SEC 1 private key from a PEM file
+func LoadKey(id string, algorithm string, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read key file %w", err)
+	}
+
+	return ParseKey(id, algorithm, data)
+}
+
+// GenerateKey Creates a new random key for the algorithm
+func GenerateKey(id string, algorithm string) (Key, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return Key{}, err
+	}
+
+	var private crypto.Signer
+	switch method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case jwt.SigningMethodES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to generate key %w", err)
+	}
+
+	return Key{Id: id, Method: method, private: private}, nil
+}
+
+// ParseKey Reads a key from PEM encoded bytes, see LoadKey
+func ParseKey(id string, algorithm string, data []byte) (Key, error) {
+	private, err := parsePrivateKey(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse key %s: %w", id, err)
+	}
+
+	return NewKey(id, algorithm, private)
+}
+
+// MarshalPEM Returns the private key as a PEM encoded PKCS #8 key
+func (k Key) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal key %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (k Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		return jwt.SigningMethodRS256, nil
+	case jwt.SigningMethodES256.Alg():
+		return jwt.SigningMethodES256, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+}
+
+func checkKeyType(method jwt.SigningMethod, private crypto.Signer) error {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if method == jwt.SigningMethodRS256 {
+			return nil
+		}
+	case *ecdsa.PrivateKey:
+		if method == jwt.SigningMethodES256 && key.Curve == elliptic.P256() {
+			return nil
+		}
+	case ed25519.PrivateKey:
+		if method == jwt.SigningMethodEdDSA {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %s", ErrKeyMismatch, method.Alg())
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("key can't sign")
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
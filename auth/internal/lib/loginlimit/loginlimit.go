@@ -0,0 +1,37 @@
+package loginlimit
+
+import "time"
+
+// Policy Limits of failed logins counted within Window. From BackoffAfter failures
+// the next attempt waits BaseDelay doubled on every failure up to MaxDelay, from
+// LockoutAfter failures the account is locked for LockoutDuration. Zero thresholds disable a limit
+type Policy struct {
+	Window          time.Duration
+	BackoffAfter    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+// maxShift Keeps the doubled delay from overflowing
+const maxShift = 30
+
+// Block Returns how long further attempts are blocked after the given number of failures
+// and whether the block is a lockout, a zero delay means no block
+func (p Policy) Block(failures int) (time.Duration, bool) {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration, true
+	}
+
+	if p.BackoffAfter <= 0 || failures < p.BackoffAfter {
+		return 0, false
+	}
+
+	delay := p.BaseDelay << min(failures-p.BackoffAfter, maxShift)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+
+	return delay, false
+}
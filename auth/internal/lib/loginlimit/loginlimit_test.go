@@ -0,0 +1,42 @@
+package loginlimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Block(t *testing.T) {
+	policy := Policy{
+		Window:          15 * time.Minute,
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 30 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{"below backoff", policy, 2, 0, false},
+		{"first backoff", policy, 3, time.Second, false},
+		{"doubled backoff", policy, 5, 4 * time.Second, false},
+		{"capped backoff", policy, 9, time.Minute, false},
+		{"lockout", policy, 10, 30 * time.Minute, true},
+		{"after lockout", policy, 40, 30 * time.Minute, true},
+		{"huge failures without lockout", Policy{BackoffAfter: 1, BaseDelay: time.Second, MaxDelay: time.Hour}, 1000, time.Hour, false},
+		{"disabled", Policy{}, 100, 0, false},
+	}
+
+	for _, test := range tests {
+		delay, locked := test.policy.Block(test.failures)
+		if delay != test.delay || locked != test.locked {
+			t.Errorf("%s: expected %s locked %v, got %s locked %v",
+				test.name, test.delay, test.locked, delay, locked)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Outbox Writes every message to a .eml file in a directory instead of sending it,
+// for local runs and tests
+type Outbox struct {
+	dir  string
+	from string
+}
+
+func NewOutbox(dir, from string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create outbox %w", err)
+	}
+
+	return &Outbox{dir: dir, from: from}, nil
+}
+
+func (o *Outbox) Send(_ context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return fmt.Errorf("invalid message %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to name message %w", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(o.dir, name), msg.build(o.from, now), 0o640); err != nil {
+		return fmt.Errorf("failed to write message %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutbox_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+
+	outbox, err := NewOutbox(dir, "auth@example.com")
+	if err != nil {
+		t.Fatalf("did not expect error, but got: %v", err)
+	}
+
+	msg := Message{To: "owner@example.com", Subject: "Verify your email", Body: "line one\nline two"}
+	if err = outbox.Send(context.Background(), msg); err != nil {
+		t.Fatalf("did not expect error on send, but got: %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected one message in outbox, got %d", len(files))
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	for _, part := range []string{
+		"From: auth@example.com\r\n",
+		"To: owner@example.com\r\n",
+		"Subject: Verify your email\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(string(data), part) {
+			t.Errorf("expected message to contain %q, got:\n%s", part, data)
+		}
+	}
+}
+
+func TestOutbox_SendRejectsHeaderInjection(t *testing.T) {
+	outbox, _ := NewOutbox(t.TempDir(), "auth@example.com")
+
+	tests := []Message{
+		{To: "", Subject: "subject"},
+		{To: "owner@example.com\r\nBcc: victim@example.com", Subject: "subject"},
+		{To: "owner@example.com", Subject: "subject\nBcc: victim@example.com"},
+	}
+
+	for _, msg := range tests {
+		if err := outbox.Send(context.Background(), msg); err == nil {
+			t.Errorf("expected error for message %+v", msg)
+		}
+	}
+}
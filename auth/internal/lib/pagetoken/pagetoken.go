@@ -0,0 +1,32 @@
+package pagetoken
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidToken = errors.New("invalid page token")
+
+// Encode Packs the position of a page into an opaque token. The token is not signed,
+// a tampered token can only move the position, it never widens what the query returns
+func Encode(position any) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Decode Unpacks a token made by Encode into the position
+func Decode(token string, position any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err = json.Unmarshal(raw, position); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package pagetoken
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type position struct {
+	Id        int64     `json:"id"`
+	CreatedAt time.Time `json:"c"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	want := position{Id: 42, CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)}
+
+	token, err := Encode(want)
+	if err != nil {
+		t.Fatalf("did not expect error on encode, but got: %v", err)
+	}
+
+	var got position
+	if err = Decode(token, &got); err != nil {
+		t.Fatalf("did not expect error on decode, but got: %v", err)
+	}
+	if got.Id != want.Id || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []string{"not base64!", "bm90IGpzb24", ""}
+
+	for _, token := range tests {
+		var p position
+		if err := Decode(token, &p); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%q: expected ErrInvalidToken, got: %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package passhash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithms new hashes are produced with
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match the hash")
+	ErrUnknownFormat = errors.New("unknown password hash format")
+	ErrInvalidParams = errors.New("invalid password hash parameters")
+)
+
+// Params Parameters of new hashes, Memory is in KiB
+type Params struct {
+	Algorithm   string
+	BcryptCost  int
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// Hasher Hashes passwords in the PHC string format. Bcrypt hashes keep their
+// own modular crypt format, so hashes stored before argon2id keep verifying
+type Hasher struct {
+	params Params
+}
+
+func New(params Params) (*Hasher, error) {
+	switch params.Algorithm {
+	case AlgorithmArgon2id:
+		if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+			return nil, fmt.Errorf("%w: argon2id memory, iterations and parallelism must be set", ErrInvalidParams)
+		}
+	case AlgorithmBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("%w: bcrypt cost %d", ErrInvalidParams, params.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidParams, params.Algorithm)
+	}
+
+	return &Hasher{params: params}, nil
+}
+
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	if h.params.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.params.BcryptCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password %w", err)
+		}
+		return hash, nil
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2KeyLen)
+
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// Verify Checks the password against a hash of any supported algorithm
+func (h *Hasher) Verify(hash []byte, password string) error {
+	if isBcrypt(hash) {
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrMismatch
+			}
+			return fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+		}
+		return nil
+	}
+
+	stored, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey(
+		[]byte(password), stored.salt, stored.params.Iterations, stored.params.Memory, stored.params.Parallelism,
+		uint32(len(stored.key)),
+	)
+	if subtle.ConstantTimeCompare(key, stored.key) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// NeedsRehash Reports whether the hash is made by another algorithm or with other parameters
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	if isBcrypt(hash) {
+		if h.params.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost != h.params.BcryptCost
+	}
+
+	stored, err := parseArgon2id(hash)
+	if err != nil || h.params.Algorithm != AlgorithmArgon2id {
+		return true
+	}
+
+	return stored.params.Memory != h.params.Memory ||
+		stored.params.Iterations != h.params.Iterations ||
+		stored.params.Parallelism != h.params.Parallelism
+}
+
+func isBcrypt(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) ||
+		bytes.HasPrefix(hash, []byte("$2b$")) ||
+		bytes.HasPrefix(hash, []byte("$2y$"))
+}
+
+type argon2idHash struct {
+	params Params
+	salt   []byte
+	key    []byte
+}
+
+// parseArgon2id Parses $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func parseArgon2id(hash []byte) (argon2idHash, error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != AlgorithmArgon2id {
+		return argon2idHash{}, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2idHash{}, fmt.Errorf("%w: argon2 version %q", ErrUnknownFormat, parts[2])
+	}
+
+	stored := argon2idHash{params: Params{Algorithm: AlgorithmArgon2id}}
+	if _, err := fmt.Sscanf(
+		parts[3], "m=%d,t=%d,p=%d", &stored.params.Memory, &stored.params.Iterations, &stored.params.Parallelism,
+	); err != nil {
+		return argon2idHash{}, fmt.Errorf("%w: argon2 parameters %q", ErrUnknownFormat, parts[3])
+	}
+
+	var err error
+	if stored.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return argon2idHash{}, fmt.Errorf("%w: salt %w", ErrUnknownFormat, err)
+	}
+	if stored.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(stored.key) == 0 {
+		return argon2idHash{}, fmt.Errorf("%w: key", ErrUnknownFormat)
+	}
+
+	return stored, nil
+}
@@ -0,0 +1,127 @@
+package passhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2id = Params{Algorithm: AlgorithmArgon2id, Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		prefix string
+	}{
+		{"argon2id", testArgon2id, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"bcrypt", Params{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, "$2a$04$"},
+	}
+
+	for _, test := range tests {
+		h, err := New(test.params)
+		if err != nil {
+			t.Fatalf("%s: failed to create hasher: %v", test.name, err)
+		}
+
+		hash, err := h.Hash("Password1!")
+		if err != nil {
+			t.Errorf("%s: did not expect error, but got: %v", test.name, err)
+			continue
+		}
+		if !strings.HasPrefix(string(hash), test.prefix) {
+			t.Errorf("%s: expected prefix %q, got %q", test.name, test.prefix, hash)
+		}
+
+		if err = h.Verify(hash, "Password1!"); err != nil {
+			t.Errorf("%s: expected the password to match, got: %v", test.name, err)
+		}
+		if err = h.Verify(hash, "Password2!"); !errors.Is(err, ErrMismatch) {
+			t.Errorf("%s: expected ErrMismatch, got: %v", test.name, err)
+		}
+		if h.NeedsRehash(hash) {
+			t.Errorf("%s: a fresh hash must not need a rehash", test.name)
+		}
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("Password1!"), bcrypt.MinCost)
+
+	weaker, _ := New(Params{Algorithm: AlgorithmArgon2id, Memory: 512, Iterations: 1, Parallelism: 1})
+	weakHash, _ := weaker.Hash("Password1!")
+
+	h, _ := New(testArgon2id)
+
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   []byte
+		expect bool
+	}{
+		{"bcrypt hash with argon2id configured", h, bcryptHash, true},
+		{"argon2id hash with other memory", h, weakHash, true},
+		{"bcrypt hash with other cost", mustNew(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: 5}), bcryptHash, true},
+		{"bcrypt hash with the same cost", mustNew(t, Params{Algorithm: AlgorithmBcrypt, BcryptCost: 4}), bcryptHash, false},
+		{"unknown format", h, []byte("plain"), true},
+	}
+
+	for _, test := range tests {
+		if got := test.hasher.NeedsRehash(test.hash); got != test.expect {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expect, got)
+		}
+	}
+
+	// An old hash keeps verifying after the parameters change
+	if err := h.Verify(bcryptHash, "Password1!"); err != nil {
+		t.Errorf("expected the bcrypt hash to verify, got: %v", err)
+	}
+	if err := h.Verify(weakHash, "Password1!"); err != nil {
+		t.Errorf("expected the argon2id hash with other memory to verify, got: %v", err)
+	}
+}
+
+func TestHasher_VerifyMalformed(t *testing.T) {
+	h, _ := New(testArgon2id)
+
+	tests := []string{
+		"",
+		"plain",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+	}
+
+	for _, hash := range tests {
+		if err := h.Verify([]byte(hash), "Password1!"); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("%q: expected ErrUnknownFormat, got: %v", hash, err)
+		}
+	}
+}
+
+func TestNew_InvalidParams(t *testing.T) {
+	tests := []Params{
+		{Algorithm: "md5"},
+		{Algorithm: AlgorithmBcrypt, BcryptCost: 100},
+		{Algorithm: AlgorithmArgon2id, Memory: 1024, Iterations: 0, Parallelism: 1},
+	}
+
+	for _, params := range tests {
+		if _, err := New(params); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%+v: expected ErrInvalidParams, got: %v", params, err)
+		}
+	}
+}
+
+func mustNew(t *testing.T, params Params) *Hasher {
+	t.Helper()
+
+	h, err := New(params)
+	if err != nil {
+		t.Fatalf("failed to create hasher: %v", err)
+	}
+	return h
+}
@@ -0,0 +1,110 @@
+package pepper
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MinKeyLen Shortest accepted pepper key
+const MinKeyLen = 32
+
+// None Version of hashes made without a pepper
+const None = 0
+
+var (
+	ErrUnknownVersion = errors.New("unknown pepper version")
+	ErrInvalidKey     = errors.New("invalid pepper key")
+)
+
+// Peppers HMAC-SHA256 keys applied to passwords before hashing. The highest
+// version peppers new hashes, older versions are kept to verify old hashes
+type Peppers struct {
+	keys    map[int][]byte
+	current int
+}
+
+// New Versions start from one, no keys leaves passwords unpeppered
+func New(keys map[int][]byte) (*Peppers, error) {
+	p := &Peppers{keys: make(map[int][]byte, len(keys)), current: None}
+	for version, key := range keys {
+		if version <= None {
+			return nil, fmt.Errorf("%w: version %d must be positive", ErrInvalidKey, version)
+		}
+		if len(key) < MinKeyLen {
+			return nil, fmt.Errorf("%w: version %d is shorter than %d bytes", ErrInvalidKey, version, MinKeyLen)
+		}
+		p.keys[version] = key
+		p.current = max(p.current, version)
+	}
+
+	return p, nil
+}
+
+// Load Reads a secret file of "<version>:<base64 key>" lines, empty lines and lines starting with # are skipped
+func Load(path string) (*Peppers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pepper file %w", err)
+	}
+
+	keys := make(map[int][]byte)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		rawVersion, rawKey, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d is not <version>:<key>", ErrInvalidKey, line)
+		}
+
+		version, errV := strconv.Atoi(strings.TrimSpace(rawVersion))
+		if errV != nil {
+			return nil, fmt.Errorf("%w: line %d version %w", ErrInvalidKey, line, errV)
+		}
+		if _, dup := keys[version]; dup {
+			return nil, fmt.Errorf("%w: line %d repeats version %d", ErrInvalidKey, line, version)
+		}
+
+		key, errK := base64.StdEncoding.DecodeString(strings.TrimSpace(rawKey))
+		if errK != nil {
+			return nil, fmt.Errorf("%w: line %d key %w", ErrInvalidKey, line, errK)
+		}
+
+		keys[version] = key
+	}
+
+	return New(keys)
+}
+
+func (p *Peppers) CurrentVersion() int {
+	return p.current
+}
+
+// Apply Returns the base64 encoded HMAC of the password, the password itself for None
+func (p *Peppers) Apply(version int, password string) (string, error) {
+	if version == None {
+		return password, nil
+	}
+
+	key, ok := p.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,95 @@
+package pepper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPeppers_Apply(t *testing.T) {
+	first := bytes.Repeat([]byte{1}, MinKeyLen)
+	second := bytes.Repeat([]byte{2}, MinKeyLen)
+
+	p, err := New(map[int][]byte{1: first, 2: second})
+	if err != nil {
+		t.Fatalf("failed to create peppers: %v", err)
+	}
+
+	if p.CurrentVersion() != 2 {
+		t.Errorf("expected current version 2, got %d", p.CurrentVersion())
+	}
+
+	plain, _ := p.Apply(None, "Password1!")
+	if plain != "Password1!" {
+		t.Errorf("expected the password unchanged without a pepper, got %q", plain)
+	}
+
+	v1, _ := p.Apply(1, "Password1!")
+	v1Again, _ := p.Apply(1, "Password1!")
+	v2, _ := p.Apply(2, "Password1!")
+	if v1 != v1Again {
+		t.Errorf("expected the same pepper result for the same version")
+	}
+	if v1 == v2 || v1 == "Password1!" {
+		t.Errorf("expected versions to pepper differently, got %q and %q", v1, v2)
+	}
+
+	if _, err = p.Apply(3, "Password1!"); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected ErrUnknownVersion, got: %v", err)
+	}
+}
+
+func TestNew_Empty(t *testing.T) {
+	p, err := New(nil)
+	if err != nil {
+		t.Fatalf("did not expect error, but got: %v", err)
+	}
+	if p.CurrentVersion() != None {
+		t.Errorf("expected no pepper, got version %d", p.CurrentVersion())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, MinKeyLen))
+	short := base64.StdEncoding.EncodeToString([]byte("short"))
+
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+		current     int
+	}{
+		{"valid", "# peppers\n1:" + key + "\n\n3:" + key + "\n", false, 3},
+		{"no separator", "1" + key, true, 0},
+		{"bad version", "x:" + key, true, 0},
+		{"zero version", "0:" + key, true, 0},
+		{"repeated version", "1:" + key + "\n1:" + key, true, 0},
+		{"bad base64", "1:***", true, 0},
+		{"short key", "1:" + short, true, 0},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "pepper")
+		if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+			t.Fatalf("failed to write pepper file: %v", err)
+		}
+
+		p, err := Load(path)
+		if test.expectError {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("%s: expected ErrInvalidKey, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: did not expect error, but got: %v", test.name, err)
+			continue
+		}
+		if p.CurrentVersion() != test.current {
+			t.Errorf("%s: expected current version %d, got %d", test.name, test.current, p.CurrentVersion())
+		}
+	}
+}
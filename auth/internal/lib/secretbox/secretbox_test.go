@@ -0,0 +1,58 @@
+package secretbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, KeyLen))
+	if err != nil {
+		t.Fatalf("did not expect error, but got: %v", err)
+	}
+
+	secret := []byte("JBSWY3DPEHPK3PXP")
+	sealed, err := box.Seal(secret)
+	if err != nil {
+		t.Fatalf("did not expect error on seal, but got: %v", err)
+	}
+	if bytes.Contains(sealed, secret) {
+		t.Errorf("sealed secret contains the plaintext")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || !bytes.Equal(opened, secret) {
+		t.Errorf("expected %q, got %q with error %v", secret, opened, err)
+	}
+
+	other, _ := New(bytes.Repeat([]byte{2}, KeyLen))
+	if _, err = other.Open(sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected ErrDecrypt with another key, got: %v", err)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err = box.Open(sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected ErrDecrypt for tampered secret, got: %v", err)
+	}
+}
+
+func TestNewFromBase64(t *testing.T) {
+	tests := []struct {
+		key         string
+		expectError bool
+	}{
+		{base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeyLen)), false},
+		{base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 16)), true},
+		{"not base64!", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		_, err := NewFromBase64(test.key)
+		if (err != nil) != test.expectError {
+			t.Errorf("For key %q expected error: %v, got: %v", test.key, test.expectError, err)
+		}
+	}
+}
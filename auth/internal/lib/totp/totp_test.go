@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret The SHA1 seed of the RFC 6238 test vectors, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTP_RFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	key, _ := encoding.DecodeString(rfcSecret)
+	for _, test := range tests {
+		if code := hotp(key, Step(time.Unix(test.unix, 0)), 8); code != test.code {
+			t.Errorf("For time %d expected code %s, got %s", test.unix, test.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+
+	current, _ := Code(rfcSecret, step)
+	previous, _ := Code(rfcSecret, step-1)
+	stale, _ := Code(rfcSecret, step-2)
+
+	tests := []struct {
+		code       string
+		expectStep int64
+		expectOk   bool
+	}{
+		{current, step, true},
+		{previous, step - 1, true},
+		{stale, 0, false},
+		{"12345", 0, false},
+		{"abcdef", 0, false},
+	}
+
+	for _, test := range tests {
+		got, ok := Validate(rfcSecret, test.code, now)
+		if ok != test.expectOk || got != test.expectStep {
+			t.Errorf("For code %q expected (%d, %v), got (%d, %v)", test.code, test.expectStep, test.expectOk, got, ok)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("did not expect error, but got: %v", err)
+	}
+
+	u, err := url.Parse(URI("grpc auth", "owner@example.com", secret))
+	if err != nil {
+		t.Fatalf("did not expect error on parse, but got: %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("unexpected uri %s", u)
+	}
+	if u.Path != "/grpc auth:owner@example.com" {
+		t.Errorf("unexpected label %q", u.Path)
+	}
+	if u.Query().Get("secret") != secret || u.Query().Get("issuer") != "grpc auth" {
+		t.Errorf("unexpected query %s", u.RawQuery)
+	}
+}
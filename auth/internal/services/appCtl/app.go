@@ -0,0 +1,45 @@
+package appCtl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+type AppCtl struct {
+	log         *slog.Logger
+	appSaver    AppSaver
+	appProvider AppProvider
+}
+
+type AppSaver interface {
+	SaveApp(ctx context.Context, app models.App) (int32, error)
+}
+
+type AppProvider interface {
+	GetApp(ctx context.Context, key models.AppKey) (models.App, error)
+	ListApps(ctx context.Context) ([]models.App, error)
+	UpdateApp(ctx context.Context, app models.App) error
+	UpdateAppSecret(ctx context.Context, id int32, secret string) error
+	DeleteApp(ctx context.Context, key models.AppKey) error
+}
+
+var (
+	ErrAppNotFound = errors.New("app not found")
+)
+
+const secretLen = 32
+
+func New(
+	log *slog.Logger,
+	appSaver AppSaver,
+	appProvider AppProvider,
+) *AppCtl {
+	return &AppCtl{
+		log:         log,
+		appSaver:    appSaver,
+		appProvider: appProvider,
+	}
+}
@@ -0,0 +1,211 @@
+package appCtl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+func (ac AppCtl) CreateApp(ctx context.Context, app models.App) (models.App, error) {
+	const op = "appCtl.CreateApp"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.String("name", app.Name()),
+	)
+
+	log.Info("create app")
+
+	secret, errGS := generateSecret()
+	if errGS != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, errGS)
+	}
+	app.SetSecret(secret)
+
+	id, err := ac.appSaver.SaveApp(ctx, app)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppExists) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppExists)
+		}
+		return models.App{}, fmt.Errorf("failed to save app %w", err)
+	}
+	_ = app.SetId(id)
+
+	log.Info("app created")
+
+	return app, nil
+}
+
+// BootstrapApp Creates the app with the name unless it exists, so that admins have an app
+// to log in to before they can create the others
+func (ac AppCtl) BootstrapApp(ctx context.Context, name string) (models.App, error) {
+	const op = "appCtl.BootstrapApp"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	app, err := ac.appProvider.GetApp(ctx, models.AppKey{Name: name})
+	if err == nil {
+		return app, nil
+	}
+	if !errors.Is(err, storage.ErrAppNotFound) {
+		return models.App{}, fmt.Errorf("%s: failed to get app %w", op, err)
+	}
+
+	if err = app.SetName(name); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app, err = ac.CreateApp(ctx, app)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("admin app created", slog.Int("id", int(app.Id())))
+
+	return app, nil
+}
+
+func (ac AppCtl) GetApp(ctx context.Context, app models.App) (models.App, error) {
+	const op = "appCtl.GetApp"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.String("name", app.Name()),
+		slog.Int("id", int(app.Id())),
+	)
+
+	log.Info("get app")
+
+	appKey := models.AppKey{Id: app.Id(), Name: app.Name()}
+	newApp, errGA := ac.appProvider.GetApp(ctx, appKey)
+	if errGA != nil {
+		if errors.Is(errGA, storage.ErrAppNotFound) {
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		return models.App{}, fmt.Errorf("failed to get app %w", errGA)
+	}
+
+	log.Info("app got")
+
+	return newApp, nil
+}
+
+func (ac AppCtl) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = "appCtl.ListApps"
+
+	log := ac.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("list apps")
+
+	apps, err := ac.appProvider.ListApps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps %w", err)
+	}
+
+	log.Info("apps listed", slog.Int("count", len(apps)))
+
+	return apps, nil
+}
+
+func (ac AppCtl) UpdateApp(ctx context.Context, app models.App) error {
+	const op = "appCtl.UpdateApp"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.Int("id", int(app.Id())),
+	)
+
+	log.Info("update app")
+
+	if err := ac.appProvider.UpdateApp(ctx, app); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		if errors.Is(err, storage.ErrAppExists) {
+			return fmt.Errorf("%s: %w", op, storage.ErrAppExists)
+		}
+
+		return fmt.Errorf("failed to update app %w", err)
+	}
+
+	log.Info("app updated")
+
+	return nil
+}
+
+func (ac AppCtl) DeleteApp(ctx context.Context, app models.App) error {
+	const op = "appCtl.DeleteApp"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.String("name", app.Name()),
+		slog.Int("id", int(app.Id())),
+	)
+
+	log.Info("delete app")
+
+	appKey := models.AppKey{Id: app.Id(), Name: app.Name()}
+	if err := ac.appProvider.DeleteApp(ctx, appKey); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		return fmt.Errorf("failed to delete app %w", err)
+	}
+
+	log.Info("app deleted")
+
+	return nil
+}
+
+func (ac AppCtl) RotateAppSecret(ctx context.Context, app models.App) (models.App, error) {
+	const op = "appCtl.RotateAppSecret"
+
+	log := ac.log.With(
+		slog.String("op", op),
+		slog.Int("id", int(app.Id())),
+	)
+
+	log.Info("rotate app secret")
+
+	secret, errGS := generateSecret()
+	if errGS != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, errGS)
+	}
+
+	if err := ac.appProvider.UpdateAppSecret(ctx, app.Id(), secret); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		return models.App{}, fmt.Errorf("failed to rotate app secret %w", err)
+	}
+
+	rotated, errGA := ac.appProvider.GetApp(ctx, models.AppKey{Id: app.Id()})
+	if errGA != nil {
+		return models.App{}, fmt.Errorf("%s: failed get app %w", op, errGA)
+	}
+
+	log.Info("app secret rotated")
+
+	return rotated, nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, secretLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate app secret %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
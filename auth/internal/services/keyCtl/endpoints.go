@@ -0,0 +1,42 @@
+package keyCtl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+)
+
+func (kc KeyCtl) RotateSigningKey(ctx context.Context) (jwt.RingKey, error) {
+	const op = "keyCtl.RotateSigningKey"
+
+	log := kc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("rotate signing key")
+
+	if kc.ring == nil {
+		return jwt.RingKey{}, fmt.Errorf("%s: %w", op, ErrKeyRingDisabled)
+	}
+
+	key, err := kc.ring.Rotate(ctx)
+	if err != nil {
+		return jwt.RingKey{}, fmt.Errorf("failed to rotate signing key %w", err)
+	}
+
+	log.Info("signing key rotated", slog.String("kid", key.Id))
+
+	return key, nil
+}
+
+func (kc KeyCtl) ListSigningKeys(_ context.Context) ([]jwt.RingKey, error) {
+	const op = "keyCtl.ListSigningKeys"
+
+	if kc.ring == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyRingDisabled)
+	}
+
+	return kc.ring.Keys(), nil
+}
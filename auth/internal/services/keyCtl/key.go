@@ -0,0 +1,34 @@
+package keyCtl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+)
+
+type KeyCtl struct {
+	log  *slog.Logger
+	ring KeyRing
+}
+
+// KeyRing Is nil when tokens are signed with configured keys or app secrets
+type KeyRing interface {
+	Rotate(ctx context.Context) (jwt.RingKey, error)
+	Keys() []jwt.RingKey
+}
+
+var (
+	ErrKeyRingDisabled = errors.New("key ring is disabled")
+)
+
+func New(
+	log *slog.Logger,
+	ring KeyRing,
+) *KeyCtl {
+	return &KeyCtl{
+		log:  log,
+		ring: ring,
+	}
+}
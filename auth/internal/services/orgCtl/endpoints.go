@@ -0,0 +1,311 @@
+package orgCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// CreateOrganization Creates the organization with the caller as its owner
+func (oc OrgCtl) CreateOrganization(
+	ctx context.Context, caller models.Caller, org models.Organization,
+) (models.Organization, error) {
+	const op = "orgCtl.CreateOrganization"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+		slog.String("slug", org.Slug),
+	)
+
+	log.Info("create organization")
+
+	id, err := oc.orgs.CreateOrganization(ctx, org, caller.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrganizationExists) {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, ErrOrganizationExists)
+		}
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, ErrOwnerNotFound)
+		}
+
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := oc.orgs.GetOrganization(ctx, id)
+	if err != nil {
+		return models.Organization{}, fmt.Errorf("%s: failed get organization %w", op, err)
+	}
+	created.Role = models.OrgRoleOwner
+
+	log.Info("organization created", slog.Int64("org_id", id))
+
+	return created, nil
+}
+
+// ListOrganizations Returns the organizations the caller is a member of or is invited to
+func (oc OrgCtl) ListOrganizations(ctx context.Context, caller models.Caller) ([]models.Organization, error) {
+	const op = "orgCtl.ListOrganizations"
+
+	orgs, err := oc.orgs.ListOwnerOrganizations(ctx, caller.Id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orgs, nil
+}
+
+// ListMembers Returns the members of an organization to its members
+func (oc OrgCtl) ListMembers(ctx context.Context, caller models.Caller, orgId int64) ([]models.Membership, error) {
+	const op = "orgCtl.ListMembers"
+
+	if _, err := oc.callerMembership(ctx, caller, orgId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	members, err := oc.orgs.ListMembers(ctx, orgId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// InviteMember Invites an existing owner found by the key to the organization, the owner becomes a member
+// once it accepts. Owners and admins of the organization invite members, only owners grant the owner role.
+// An unknown owner is not reported, so the invitations do not tell which accounts exist
+func (oc OrgCtl) InviteMember(
+	ctx context.Context, caller models.Caller, member models.Membership, key models.OwnerKey,
+) error {
+	const op = "orgCtl.InviteMember"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+		slog.Int64("org_id", member.OrgId),
+	)
+
+	log.Info("invite member")
+
+	actor, err := oc.callerMembership(ctx, caller, member.OrgId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !actor.ManagesMembers() || (member.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner) {
+		return fmt.Errorf("%s: %w: role %s", op, ErrForbidden, actor.Role)
+	}
+
+	// The external id is checked before the owner is looked up to answer the same for unknown owners
+	if member.ExternalId != "" {
+		used, errEU := oc.orgs.ExternalIdUsed(ctx, member.OrgId, member.ExternalId)
+		if errEU != nil {
+			return fmt.Errorf("%s: failed check external id %w", op, errEU)
+		}
+		if used {
+			return fmt.Errorf("%s: %w", op, ErrExternalIdExists)
+		}
+	}
+
+	invited, err := oc.ownerProvider.GetOwner(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			log.Info("invited owner not found")
+			return nil
+		}
+		return fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+	member.OwnerId = invited.Id()
+
+	if err = oc.orgs.AddMember(ctx, member); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrMemberExists):
+			return fmt.Errorf("%s: %w", op, ErrMemberExists)
+		case errors.Is(err, storage.ErrExternalIdExists):
+			return fmt.Errorf("%s: %w", op, ErrExternalIdExists)
+		case errors.Is(err, storage.ErrOrganizationNotFound):
+			return fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+		case errors.Is(err, storage.ErrOwnerNotFound):
+			log.Info("invited owner not found")
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("member invited", slog.Int64("owner_id", member.OwnerId), slog.String("role", member.Role))
+
+	return nil
+}
+
+// AcceptInvitation Makes the caller a member of the organization it is invited to
+func (oc OrgCtl) AcceptInvitation(ctx context.Context, caller models.Caller, orgId int64) (models.Organization, error) {
+	const op = "orgCtl.AcceptInvitation"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+		slog.Int64("org_id", orgId),
+	)
+
+	log.Info("accept invitation")
+
+	if err := oc.orgs.AcceptMember(ctx, orgId, caller.Id); err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return models.Organization{}, fmt.Errorf("%s: %w", op, ErrInvitationNotFound)
+		}
+		return models.Organization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	member, err := oc.orgs.GetMembership(ctx, orgId, caller.Id)
+	if err != nil {
+		return models.Organization{}, fmt.Errorf("%s: failed get membership %w", op, err)
+	}
+
+	org, err := oc.orgs.GetOrganization(ctx, orgId)
+	if err != nil {
+		return models.Organization{}, fmt.Errorf("%s: failed get organization %w", op, err)
+	}
+	org.Role = member.Role
+
+	log.Info("invitation accepted", slog.String("role", member.Role))
+
+	return org, nil
+}
+
+// CreateMember Creates an account of an organization with scoped accounts, the account is its member at once.
+// Owners and admins of the organization create accounts, only owners grant the owner role
+func (oc OrgCtl) CreateMember(
+	ctx context.Context, caller models.Caller, member models.Membership, owner models.Owner,
+) (models.Membership, error) {
+	const op = "orgCtl.CreateMember"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+		slog.Int64("org_id", member.OrgId),
+	)
+
+	log.Info("create member")
+
+	actor, err := oc.callerMembership(ctx, caller, member.OrgId)
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !actor.ManagesMembers() || (member.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner) {
+		return models.Membership{}, fmt.Errorf("%s: %w: role %s", op, ErrForbidden, actor.Role)
+	}
+
+	org, err := oc.orgs.GetOrganization(ctx, member.OrgId)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrganizationNotFound) {
+			return models.Membership{}, fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+		}
+		return models.Membership{}, fmt.Errorf("%s: failed get organization %w", op, err)
+	}
+	if !org.ScopedAccounts {
+		return models.Membership{}, fmt.Errorf("%s: %w", op, ErrNotScoped)
+	}
+
+	owner.SetOrgId(org.Id)
+	id, err := oc.accounts.CreateOrgOwner(ctx, owner, member)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrOwnerExists):
+			return models.Membership{}, fmt.Errorf("%s: %w", op, ErrOwnerExists)
+		case errors.Is(err, storage.ErrExternalIdExists):
+			return models.Membership{}, fmt.Errorf("%s: %w", op, ErrExternalIdExists)
+		case errors.Is(err, storage.ErrOrganizationNotFound):
+			return models.Membership{}, fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+		}
+		return models.Membership{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := oc.orgs.GetMembership(ctx, org.Id, id)
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("%s: failed get membership %w", op, err)
+	}
+
+	log.Info("member created", slog.Int64("owner_id", id), slog.String("role", created.Role))
+
+	return created, nil
+}
+
+// RemoveMember Removes a member from the organization and revokes its sessions logged in to it.
+// Members may leave and invited owners decline this way, owners and admins remove members,
+// only owners remove owners
+func (oc OrgCtl) RemoveMember(ctx context.Context, caller models.Caller, orgId int64, ownerId int64) error {
+	const op = "orgCtl.RemoveMember"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+		slog.Int64("org_id", orgId),
+		slog.Int64("owner_id", ownerId),
+	)
+
+	log.Info("remove member")
+
+	if ownerId != caller.Id {
+		actor, err := oc.callerMembership(ctx, caller, orgId)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		member, err := oc.orgs.GetMembership(ctx, orgId, ownerId)
+		if err != nil {
+			if errors.Is(err, storage.ErrMemberNotFound) {
+				return fmt.Errorf("%s: %w", op, ErrMemberNotFound)
+			}
+			return fmt.Errorf("%s: failed get membership %w", op, err)
+		}
+		// Invitations are not shown to the organization, so they are not found either
+		if member.Pending {
+			return fmt.Errorf("%s: %w", op, ErrMemberNotFound)
+		}
+
+		if !actor.ManagesMembers() || (member.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner) {
+			return fmt.Errorf("%s: %w: role %s", op, ErrForbidden, actor.Role)
+		}
+	}
+
+	if err := oc.orgs.RemoveMember(ctx, orgId, ownerId); err != nil {
+		if errors.Is(err, storage.ErrLastOrgOwner) {
+			return fmt.Errorf("%s: %w", op, ErrLastOwner)
+		}
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			// The caller leaving an organization it is not in is told about the organization
+			if ownerId == caller.Id {
+				return fmt.Errorf("%s: %w", op, ErrOrganizationNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, ErrMemberNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := oc.sessions.RevokeOrgSessions(ctx, ownerId, orgId); err != nil {
+		return fmt.Errorf("%s: failed revoke sessions %w", op, err)
+	}
+
+	log.Info("member removed")
+
+	return nil
+}
+
+// callerMembership Organizations of other owners and those the caller is only invited to are reported as not found
+func (oc OrgCtl) callerMembership(ctx context.Context, caller models.Caller, orgId int64) (models.Membership, error) {
+	member, err := oc.orgs.GetMembership(ctx, orgId, caller.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return models.Membership{}, ErrOrganizationNotFound
+		}
+		return models.Membership{}, fmt.Errorf("failed get membership %w", err)
+	}
+	if member.Pending {
+		return models.Membership{}, ErrOrganizationNotFound
+	}
+
+	return member, nil
+}
@@ -0,0 +1,73 @@
+package orgCtl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+type OrgCtl struct {
+	log           *slog.Logger
+	orgs          OrganizationProvider
+	ownerProvider OwnerProvider
+	sessions      SessionRevoker
+	accounts      AccountCreator
+}
+
+type OrganizationProvider interface {
+	CreateOrganization(ctx context.Context, org models.Organization, creatorId int64) (int64, error)
+	GetOrganization(ctx context.Context, id int64) (models.Organization, error)
+	ListOwnerOrganizations(ctx context.Context, ownerId int64) ([]models.Organization, error)
+	GetMembership(ctx context.Context, orgId int64, ownerId int64) (models.Membership, error)
+	ListMembers(ctx context.Context, orgId int64) ([]models.Membership, error)
+	AddMember(ctx context.Context, member models.Membership) error
+	ExternalIdUsed(ctx context.Context, orgId int64, externalId string) (bool, error)
+	AcceptMember(ctx context.Context, orgId int64, ownerId int64) error
+	RemoveMember(ctx context.Context, orgId int64, ownerId int64) error
+}
+
+type OwnerProvider interface {
+	GetOwner(ctx context.Context, key models.OwnerKey) (models.Owner, error)
+}
+
+// AccountCreator Creates the accounts of organizations with scoped accounts together with their membership
+type AccountCreator interface {
+	CreateOrgOwner(ctx context.Context, owner models.Owner, member models.Membership) (int64, error)
+}
+
+// SessionRevoker Logs removed members out of the organization
+type SessionRevoker interface {
+	RevokeOrgSessions(ctx context.Context, ownerId int64, orgId int64) error
+}
+
+var (
+	ErrOrganizationExists   = errors.New("organization already exists")
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOwnerNotFound        = errors.New("owner not found")
+	ErrOwnerExists          = errors.New("owner already exists")
+	ErrNotScoped            = errors.New("organization has no scoped accounts")
+	ErrMemberExists         = errors.New("member already exists")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrExternalIdExists     = errors.New("external id already used in organization")
+	ErrLastOwner            = errors.New("organization must keep an owner")
+	ErrForbidden            = errors.New("not allowed in organization")
+)
+
+func New(
+	log *slog.Logger,
+	orgs OrganizationProvider,
+	ownerProvider OwnerProvider,
+	sessions SessionRevoker,
+	accounts AccountCreator,
+) *OrgCtl {
+	return &OrgCtl{
+		log:           log,
+		orgs:          orgs,
+		ownerProvider: ownerProvider,
+		sessions:      sessions,
+		accounts:      accounts,
+	}
+}
@@ -0,0 +1,123 @@
+package ownerCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/actiontoken"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/mailer"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const verificationSubject = "Verify your email"
+
+// SendVerificationEmail Sends a verification link to the owner with the login,
+// orgId names the organization of a scoped account.
+// An unknown login or a verified email is not an error, so logins can't be probed
+func (oc OwnerCtl) SendVerificationEmail(ctx context.Context, login string, orgId int64) error {
+	const op = "ownerCtl.SendVerificationEmail"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", login),
+		slog.Int64("org_id", orgId),
+	)
+
+	log.Info("send verification email")
+
+	owner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Login: login, OrgId: orgId})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			log.Info("owner not found, nothing to send")
+			return nil
+		}
+		return fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+
+	if owner.EmailVerified() {
+		log.Info("email already verified, nothing to send")
+		return nil
+	}
+
+	if err = oc.sendVerificationEmail(ctx, owner); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("verification email sent")
+
+	return nil
+}
+
+// VerifyEmail Marks the email as verified by a token from the verification email
+func (oc OwnerCtl) VerifyEmail(ctx context.Context, token string) error {
+	const op = "ownerCtl.VerifyEmail"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("verify email")
+
+	claims, err := oc.actionTokens.Verify(actiontoken.PurposeVerifyEmail, token)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidToken, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	// The owner could have changed the email after the token was sent or used the token already
+	if err = oc.ownerProvider.VerifyOwnerEmail(ctx, claims.Uid, claims.Email); err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w: email changed or verified", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email verified")
+
+	return nil
+}
+
+// sendVerificationEmail Sends a token bound to the current email of the owner
+func (oc OwnerCtl) sendVerificationEmail(ctx context.Context, owner models.Owner) error {
+	token, err := oc.actionTokens.Sign(
+		actiontoken.PurposeVerifyEmail,
+		actiontoken.Claims{Uid: owner.Id(), Email: owner.Email()},
+		oc.cfg.VerificationTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hello, %s!\n\nConfirm your email by opening the link below, it is valid for %s.\n\n%s%s\n\n"+
+			"If you did not create an account, ignore this email.\n",
+		owner.Login(), oc.cfg.VerificationTTL, oc.cfg.VerificationURL, token,
+	)
+
+	if err = oc.mailer.Send(ctx, mailer.Message{
+		To:      owner.Email(),
+		Subject: verificationSubject,
+		Body:    body,
+	}); err != nil {
+		return fmt.Errorf("failed to send verification email %w", err)
+	}
+
+	return nil
+}
+
+// sendWelcomeVerification Sends the first verification email of a created owner,
+// a failure is only logged since the owner can ask for the email again
+func (oc OwnerCtl) sendWelcomeVerification(ctx context.Context, log *slog.Logger, key models.OwnerKey) {
+	owner, err := oc.ownerProvider.GetOwner(ctx, key)
+	if err == nil {
+		err = oc.sendVerificationEmail(ctx, owner)
+	}
+	if err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
+}
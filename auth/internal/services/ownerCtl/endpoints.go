@@ -5,10 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-
-	"golang.org/x/crypto/bcrypt"
+	"slices"
+	"time"
 
 	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
 	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
 	"github.com/viacheslavek/grpcauth/auth/internal/storage"
 )
@@ -23,11 +24,12 @@ func (oc OwnerCtl) CreateOwner(ctx context.Context, owner models.Owner) error {
 
 	log.Info("create owner")
 
-	passwordHash, errGPH := getPasswordHash(owner.Password())
+	passwordHash, pepperVersion, errGPH := oc.getPasswordHash(owner.Password())
 	if errGPH != nil {
 		return errGPH
 	}
 	owner.SetPassHash(passwordHash)
+	owner.SetPepperVersion(pepperVersion)
 
 	if err := oc.ownerSaver.SaveOwner(ctx, owner); err != nil {
 		if errors.Is(err, storage.ErrOwnerExists) {
@@ -38,10 +40,45 @@ func (oc OwnerCtl) CreateOwner(ctx context.Context, owner models.Owner) error {
 
 	log.Info("owner created")
 
+	oc.sendWelcomeVerification(ctx, log, models.OwnerKey{Login: owner.Login()})
+
 	return nil
 }
 
-func (oc OwnerCtl) UpdateOwner(ctx context.Context, owner models.Owner) error {
+// CreateOrgOwner Creates an account of the organization of the owner, the account is its member at once
+// with the role and the external id of the membership. Returns the id of the account
+func (oc OwnerCtl) CreateOrgOwner(ctx context.Context, owner models.Owner, member models.Membership) (int64, error) {
+	const op = "ownerCtl.CreateOrgOwner"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+		slog.Int64("org_id", owner.OrgId()),
+	)
+
+	log.Info("create organization account")
+
+	passwordHash, pepperVersion, errGPH := oc.getPasswordHash(owner.Password())
+	if errGPH != nil {
+		return 0, errGPH
+	}
+	owner.SetPassHash(passwordHash)
+	owner.SetPepperVersion(pepperVersion)
+
+	id, err := oc.ownerSaver.SaveOrgOwner(ctx, owner, member)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("organization account created", slog.Int64("id", id))
+
+	oc.sendWelcomeVerification(ctx, log, models.OwnerKey{Id: id})
+
+	return id, nil
+}
+
+// UpdateOwner Changes only the fields of the owner that are named, the password is hashed before it is stored
+func (oc OwnerCtl) UpdateOwner(ctx context.Context, owner models.Owner, fields []string) error {
 	const op = "ownerCtl.UpdateOwner"
 
 	log := oc.log.With(
@@ -49,20 +86,46 @@ func (oc OwnerCtl) UpdateOwner(ctx context.Context, owner models.Owner) error {
 		slog.Int("id", int(owner.Id())),
 	)
 
-	log.Info("update owner")
+	log.Info("update owner", slog.Any("fields", fields))
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNothingToUpdate)
+	}
 
-	if len(owner.Password()) != 0 {
-		passwordHash, errGPH := getPasswordHash(owner.Password())
+	// dbOwner Holds the replaced password hash when the password is updated
+	var dbOwner models.Owner
+	withPassword := slices.Contains(fields, models.OwnerFieldPassword)
+	if withPassword {
+		var errGO error
+		dbOwner, errGO = oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: owner.Id()})
+		if errGO != nil {
+			if errors.Is(errGO, storage.ErrOwnerNotFound) {
+				return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}
+			return fmt.Errorf("%s: failed get owner %w", op, errGO)
+		}
+		if err := checkPasswordPolicy(owner.Password(), owner, dbOwner); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := oc.checkPasswordReuse(ctx, dbOwner, owner.Password()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		passwordHash, pepperVersion, errGPH := oc.getPasswordHash(owner.Password())
 		if errGPH != nil {
 			return errGPH
 		}
 		owner.SetPassHash(passwordHash)
+		owner.SetPepperVersion(pepperVersion)
 	}
 
-	if err := oc.ownerProvider.UpdateOwner(ctx, owner); err != nil {
+	if err := oc.ownerProvider.UpdateOwner(ctx, owner, fields, oc.historyKeep()); err != nil {
 		if errors.Is(err, storage.ErrOwnerNotFound) {
 			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
+		if errors.Is(err, storage.ErrOwnerExists) {
+			return fmt.Errorf("%s: %w", op, storage.ErrOwnerExists)
+		}
 
 		return fmt.Errorf("failed to update owner %w", err)
 	}
@@ -118,52 +181,335 @@ func (oc OwnerCtl) GetOwner(ctx context.Context, owner models.Owner) (models.Own
 		return models.Owner{}, fmt.Errorf("failed to get owner %w", errGO)
 	}
 
+	if err := oc.loadMFAState(ctx, &newOwner); err != nil {
+		return models.Owner{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := oc.loadLockState(ctx, &newOwner); err != nil {
+		return models.Owner{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	log.Info("owner got")
 
 	return newOwner, nil
 }
 
-func (oc OwnerCtl) LoginOwner(ctx context.Context, owner models.Owner, appId int) (token string, err error) {
+// LoginOwner Logs the owner in to the app, and to the organization when orgId is not zero
+func (oc OwnerCtl) LoginOwner(
+	ctx context.Context, owner models.Owner, appId int32, orgId int64, client models.ClientInfo,
+) (models.Tokens, error) {
 	const op = "ownerCtl.LoginOwner"
 
-	// TODO: разобраться с нужности ручки loginOwner
-	fmt.Println("app id", appId)
-
 	log := oc.log.With(
 		slog.String("op", op),
 		slog.String("login", owner.Login()),
+		slog.Int("app_id", int(appId)),
+		slog.Int64("org_id", orgId),
 	)
 
 	log.Info("login owner")
 
-	ownerKey := models.OwnerKey{Id: owner.Id(), Login: owner.Login()}
-	dbOwner, errGO := oc.ownerProvider.GetOwner(ctx, ownerKey)
+	app, errGA := oc.appProvider.GetApp(ctx, models.AppKey{Id: appId})
+	if errGA != nil {
+		if errors.Is(errGA, storage.ErrAppNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		return models.Tokens{}, fmt.Errorf("%s: failed get app %w", op, errGA)
+	}
+
+	dbOwner, found, errLO := oc.loginOwner(ctx, owner, orgId)
+	if errLO != nil {
+		return models.Tokens{}, fmt.Errorf("%s: failed get owner %w", op, errLO)
+	}
+
+	// Blocked attempts are rejected before they are counted, the attempt is counted
+	// as a failure until the password matches. An unknown login is counted as an owner without an organization
+	attemptKeys := loginAttemptKeys(dbOwner.OrgId(), owner.Login(), client)
+	if err := oc.checkLoginBlocked(ctx, attemptKeys); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := oc.reserveLoginAttempt(ctx, log, attemptKeys); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !found {
+		// An unknown login pays for a password check too, so the time doesn't tell which logins exist
+		oc.verifyUnknownPassword(owner.Password())
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	if err := oc.verifyPassword(dbOwner, owner.Password()); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	oc.releaseLoginAttempt(ctx, log, attemptKeys)
+
+	oc.rehashPassword(ctx, log, dbOwner, owner.Password())
+
+	if oc.cfg.RequireVerifiedEmail && !dbOwner.EmailVerified() {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+
+	org, errOM := oc.orgMembership(ctx, dbOwner.Id(), orgId)
+	if errOM != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, errOM)
+	}
+
+	mfaToken, errSM := oc.startMFA(ctx, dbOwner, app, orgId)
+	if errSM != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, errSM)
+	}
+	if mfaToken != "" {
+		log.Info("owner must pass mfa")
+
+		return models.Tokens{MFAToken: mfaToken}, nil
+	}
+
+	log.Info("owner logged in successfully")
+
+	tokens, err := oc.startSession(ctx, dbOwner, app, org, client)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+func (oc OwnerCtl) RefreshToken(
+	ctx context.Context, refreshToken string, client models.ClientInfo,
+) (models.Tokens, error) {
+	const op = "ownerCtl.RefreshToken"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("refresh token")
+
+	stored, errGRT := oc.tokenProvider.GetRefreshToken(ctx, hashOpaqueToken(refreshToken))
+	if errGRT != nil {
+		if errors.Is(errGRT, storage.ErrRefreshTokenNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return models.Tokens{}, fmt.Errorf("%s: failed get refresh token %w", op, errGRT)
+	}
+
+	log = log.With(
+		slog.Int64("owner_id", stored.OwnerId),
+		slog.String("family_id", stored.FamilyId),
+	)
+
+	if stored.RevokedAt != nil {
+		return models.Tokens{}, fmt.Errorf("%s: refresh token revoked %w", op, ErrInvalidToken)
+	}
+	if stored.UsedAt != nil {
+		return models.Tokens{}, oc.revokeReusedFamily(ctx, log, op, stored)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return models.Tokens{}, fmt.Errorf("%s: refresh token expired %w", op, ErrInvalidToken)
+	}
+
+	if err := oc.tokenProvider.UseRefreshToken(ctx, stored.Id); err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenUsed) {
+			return models.Tokens{}, oc.revokeReusedFamily(ctx, log, op, stored)
+		}
+
+		return models.Tokens{}, fmt.Errorf("%s: failed use refresh token %w", op, err)
+	}
+
+	dbOwner, errGO := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: stored.OwnerId})
 	if errGO != nil {
 		if errors.Is(errGO, storage.ErrOwnerNotFound) {
-			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
 		}
 
-		return "", fmt.Errorf("%s: failed get owner %w", op, errGO)
+		return models.Tokens{}, fmt.Errorf("%s: failed get owner %w", op, errGO)
 	}
 
-	if err = bcrypt.CompareHashAndPassword(dbOwner.PassHash(), []byte(owner.Password())); err != nil {
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	app, errGA := oc.appProvider.GetApp(ctx, models.AppKey{Id: stored.AppId})
+	if errGA != nil {
+		if errors.Is(errGA, storage.ErrAppNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return models.Tokens{}, fmt.Errorf("%s: failed get app %w", op, errGA)
 	}
 
-	log.Info("owner logged in successfully")
+	session, errGS := oc.sessions.GetSession(ctx, stored.FamilyId)
+	if errGS != nil {
+		if errors.Is(errGS, storage.ErrSessionNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return models.Tokens{}, fmt.Errorf("%s: failed get session %w", op, errGS)
+	}
+
+	// The owner may have left the organization the session is logged in to
+	org, errOM := oc.orgMembership(ctx, dbOwner.Id(), session.OrgId)
+	if errOM != nil {
+		if errors.Is(errOM, ErrNotOrgMember) {
+			return models.Tokens{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidToken, errOM)
+		}
 
-	token, err = jwt.NewToken(owner, oc.tokenTTL)
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, errOM)
+	}
+
+	if err := oc.sessions.TouchSession(ctx, stored.FamilyId, client); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: failed touch session %w", op, err)
+	}
+
+	tokens, err := oc.issueTokens(ctx, dbOwner, app, org, stored.FamilyId)
 	if err != nil {
-		return "", fmt.Errorf("%s: failed to generate token %w", op, err)
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return token, nil
+	log.Info("token refreshed")
+
+	return tokens, nil
 }
 
-func getPasswordHash(password string) ([]byte, error) {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (oc OwnerCtl) IntrospectToken(ctx context.Context, token string, appId int32) (jwt.Claims, error) {
+	const op = "ownerCtl.IntrospectToken"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int("app_id", int(appId)),
+	)
+
+	log.Info("introspect token")
+
+	claims, err := oc.verifyAccessToken(ctx, token)
 	if err != nil {
-		return []byte{}, fmt.Errorf("failed to get password hash %w", err)
+		if errors.Is(err, ErrInvalidToken) {
+			log.Info("token is inactive", slog.String("reason", err.Error()))
+		}
+
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if appId != 0 && claims.AppId != appId {
+		log.Info("token is inactive", slog.String("reason", "issued for another app"))
+
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
 	}
-	return passwordHash, nil
+
+	log.Info("token is active")
+
+	return claims, nil
+}
+
+func (oc OwnerCtl) RevokeToken(ctx context.Context, token string, tokenTypeHint string) error {
+	const op = "ownerCtl.RevokeToken"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("token_type_hint", tokenTypeHint),
+	)
+
+	log.Info("revoke token")
+
+	// An invalid token is not an error, there is nothing left to revoke (RFC 7009)
+	if tokenTypeHint != TokenTypeRefresh {
+		revoked, err := oc.revokeAccessToken(ctx, token)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if revoked || tokenTypeHint == TokenTypeAccess {
+			log.Info("token revoked")
+			return nil
+		}
+	}
+
+	if err := oc.revokeRefreshToken(ctx, token, 0); err != nil && !errors.Is(err, ErrInvalidToken) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("token revoked")
+
+	return nil
+}
+
+func (oc OwnerCtl) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	const op = "ownerCtl.Logout"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("logout")
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	if err = oc.denylist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if claims.Sid != "" {
+		if err = oc.sessions.RevokeSession(ctx, claims.Sid); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if refreshToken != "" {
+		if err = oc.revokeRefreshToken(ctx, refreshToken, claims.Uid); err != nil && !errors.Is(err, ErrInvalidToken) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("owner logged out")
+
+	return nil
+}
+
+func (oc OwnerCtl) GetJWKS(_ context.Context) jwt.JWKS {
+	return oc.tokens.JWKS()
+}
+
+func (oc OwnerCtl) GetPasswordPolicy(_ context.Context) validator.PasswordPolicy {
+	return validator.CurrentPasswordPolicy()
+}
+
+// getPasswordHash Hashes the password peppered with the current pepper version
+func (oc OwnerCtl) getPasswordHash(password string) ([]byte, int, error) {
+	version := oc.pepper.CurrentVersion()
+
+	peppered, err := oc.pepper.Apply(version, password)
+	if err != nil {
+		return []byte{}, 0, fmt.Errorf("failed to pepper password %w", err)
+	}
+
+	passwordHash, err := oc.passwords.Hash(peppered)
+	if err != nil {
+		return []byte{}, 0, fmt.Errorf("failed to get password hash %w", err)
+	}
+	return passwordHash, version, nil
+}
+
+// verifyUnknownPassword Checks the password against a hash of no owner with the current settings,
+// it never matches
+func (oc OwnerCtl) verifyUnknownPassword(password string) {
+	peppered, err := oc.pepper.Apply(oc.pepper.CurrentVersion(), password)
+	if err != nil {
+		return
+	}
+	_ = oc.passwords.Verify(oc.dummyHash, peppered)
+}
+
+// verifyPassword Checks the password with the pepper version the hash of the owner is made with
+func (oc OwnerCtl) verifyPassword(owner models.Owner, password string) error {
+	peppered, err := oc.pepper.Apply(owner.PepperVersion(), password)
+	if err != nil {
+		return fmt.Errorf("failed to pepper password %w", err)
+	}
+
+	if err = oc.passwords.Verify(owner.PassHash(), peppered); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	return nil
 }
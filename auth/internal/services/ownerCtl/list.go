@@ -0,0 +1,98 @@
+package ownerCtl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/pagetoken"
+)
+
+// ListOwners Returns a page of owners matching the filter of the query in its order and the token
+// of the next page, empty on the last page. The page token has to come from the same order
+func (oc OwnerCtl) ListOwners(
+	ctx context.Context, q models.OwnerListQuery, pageToken string,
+) ([]models.Owner, string, error) {
+	const op = "ownerCtl.ListOwners"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("sort", q.Sort),
+		slog.Bool("desc", q.Desc),
+	)
+
+	log.Info("list owners")
+
+	if pageToken != "" {
+		var cursor models.OwnerCursor
+		if err := pagetoken.Decode(pageToken, &cursor); err != nil {
+			return nil, "", fmt.Errorf("%s: %w: %w", op, ErrInvalidPageToken, err)
+		}
+		if cursor.Sort != q.Sort || cursor.Desc != q.Desc {
+			return nil, "", fmt.Errorf("%s: %w: issued for another order", op, ErrInvalidPageToken)
+		}
+		q.After = &cursor
+	}
+
+	q.Limit = oc.pageSize(q.Limit)
+
+	// One more owner than the page holds tells whether there is a next page
+	pageLimit := q.Limit
+	q.Limit++
+
+	owners, err := oc.ownerProvider.ListOwners(ctx, q)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var next string
+	if len(owners) > pageLimit {
+		owners = owners[:pageLimit]
+		next, err = pagetoken.Encode(q.CursorAfter(owners[pageLimit-1]))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("owners listed", slog.Int("count", len(owners)), slog.Bool("more", next != ""))
+
+	return owners, next, nil
+}
+
+// SearchOwners Finds owners by a partial or misspelled login or email, the best matches first.
+// Like GetOwner, callers without the permission to read owners only find themselves
+func (oc OwnerCtl) SearchOwners(
+	ctx context.Context, caller models.Caller, q models.OwnerSearch,
+) ([]models.OwnerMatch, error) {
+	const op = "ownerCtl.SearchOwners"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", caller.Id),
+	)
+
+	log.Info("search owners")
+
+	if !caller.HasPermission(models.PermOwnersRead) {
+		q.OwnerId = caller.Id
+	}
+	q.Limit = oc.pageSize(q.Limit)
+
+	matches, err := oc.ownerProvider.SearchOwners(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("owners found", slog.Int("count", len(matches)))
+
+	return matches, nil
+}
+
+// pageSize Applies the default page size and caps the requested one
+func (oc OwnerCtl) pageSize(requested int) int {
+	if requested <= 0 {
+		requested = oc.cfg.ListPageSize
+	}
+	return max(1, min(requested, oc.cfg.MaxListPageSize))
+}
@@ -0,0 +1,222 @@
+package ownerCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/loginlimit"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// RetryAfterError Tells when a blocked login can be tried again
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// UnlockOwner Lifts the lockout and the backoff of the owner logins and of its second factor
+func (oc OwnerCtl) UnlockOwner(ctx context.Context, owner models.Owner) error {
+	const op = "ownerCtl.UnlockOwner"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+		slog.Int("id", int(owner.Id())),
+	)
+
+	log.Info("unlock owner")
+
+	dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: owner.Id(), Login: owner.Login()})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		return fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+
+	existed, err := oc.loginAttempts.ResetLoginAttempts(ctx, loginAttemptKey(dbOwner.OrgId(), dbOwner.Login()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	existedMFA, err := oc.loginAttempts.ResetLoginAttempts(ctx, mfaAttemptKey(dbOwner.Id()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	existed = existed || existedMFA
+
+	log.Info("owner unlocked", slog.Bool("had_failures", existed))
+
+	return nil
+}
+
+// PruneLoginAttempts Deletes the counters whose failures left the window until ctx is done,
+// so that logins and ips tried once don't pile up
+func (oc OwnerCtl) PruneLoginAttempts(ctx context.Context, interval time.Duration) {
+	const op = "ownerCtl.PruneLoginAttempts"
+
+	log := oc.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			for _, kind := range []string{models.LoginAttemptLogin, models.LoginAttemptIP, models.LoginAttemptMFA} {
+				window := oc.loginPolicy(models.LoginAttemptKey{Kind: kind}).Window
+
+				pruned, err := oc.loginAttempts.DeleteExpiredLoginAttempts(ctx, kind, now.Add(-window), now)
+				if err != nil {
+					log.Error("failed to prune login attempts", sl.Err(err), slog.String("kind", kind))
+					continue
+				}
+				log.Debug("login attempts pruned", slog.String("kind", kind), slog.Int64("count", pruned))
+			}
+		}
+	}
+}
+
+// loadLockState Sets whether the logins of the owner are locked out right now
+func (oc OwnerCtl) loadLockState(ctx context.Context, owner *models.Owner) error {
+	attempts, err := oc.loginAttempts.GetLoginAttempts(ctx, []models.LoginAttemptKey{loginAttemptKey(owner.OrgId(), owner.Login())})
+	if err != nil {
+		return fmt.Errorf("failed get login attempts %w", err)
+	}
+
+	now := time.Now()
+	owner.SetLocked(slices.ContainsFunc(attempts, func(a models.LoginAttempts) bool {
+		return a.Locked && a.BlockedUntil != nil && a.BlockedUntil.After(now)
+	}))
+
+	return nil
+}
+
+// loginAttemptKeys Returns the counters of the login and of the client ip, the login counter first
+func loginAttemptKeys(orgId int64, login string, client models.ClientInfo) []models.LoginAttemptKey {
+	keys := []models.LoginAttemptKey{loginAttemptKey(orgId, login)}
+	if client.IP != "" {
+		keys = append(keys, models.LoginAttemptKey{Kind: models.LoginAttemptIP, Subject: client.IP})
+	}
+	return keys
+}
+
+// loginAttemptKey Counts the logins of an account of an organization apart from an owner with the same login,
+// logins are alphanumeric so the organization prefix can't be forged
+func loginAttemptKey(orgId int64, login string) models.LoginAttemptKey {
+	if orgId != 0 {
+		login = fmt.Sprintf("%d:%s", orgId, login)
+	}
+	return models.LoginAttemptKey{Kind: models.LoginAttemptLogin, Subject: login}
+}
+
+// checkLoginBlocked Returns a RetryAfterError when any of the counters is blocked
+func (oc OwnerCtl) checkLoginBlocked(ctx context.Context, keys []models.LoginAttemptKey) error {
+	attempts, err := oc.loginAttempts.GetLoginAttempts(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed get login attempts %w", err)
+	}
+
+	return blockedError(attempts, time.Now())
+}
+
+// blockedError Returns a RetryAfterError for the counters blocked at the time, a lockout wins over a backoff
+func blockedError(attempts []models.LoginAttempts, now time.Time) error {
+	var blocked *RetryAfterError
+	for _, a := range attempts {
+		if a.BlockedUntil == nil || !a.BlockedUntil.After(now) {
+			continue
+		}
+
+		cause := ErrLoginThrottled
+		if a.Locked {
+			cause = ErrOwnerLocked
+		}
+		retryAfter := (a.BlockedUntil.Sub(now) + time.Second - 1).Truncate(time.Second)
+
+		if blocked == nil ||
+			(a.Locked && !errors.Is(blocked.Err, ErrOwnerLocked)) ||
+			(a.Locked == errors.Is(blocked.Err, ErrOwnerLocked) && retryAfter > blocked.RetryAfter) {
+			blocked = &RetryAfterError{Err: cause, RetryAfter: retryAfter}
+		}
+	}
+
+	if blocked != nil {
+		return blocked
+	}
+
+	return nil
+}
+
+// reserveLoginAttempt Counts the attempt as a failure on every counter before the password is checked,
+// so that concurrent guesses are blocked as soon as the failures reach a limit instead of after
+// they are checked. A counter blocked meanwhile rejects the attempt
+func (oc OwnerCtl) reserveLoginAttempt(
+	ctx context.Context, log *slog.Logger, keys []models.LoginAttemptKey,
+) error {
+	now := time.Now()
+
+	for _, key := range keys {
+		policy := oc.loginPolicy(key)
+
+		attempts, reserved, err := oc.loginAttempts.ReserveLoginAttempt(
+			ctx, key, now, now.Add(-policy.Window), policy.Block,
+		)
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			return blockedError([]models.LoginAttempts{attempts}, now)
+		}
+
+		if attempts.BlockedUntil != nil {
+			log.Warn("login blocked",
+				slog.String("kind", key.Kind),
+				slog.Int("failures", attempts.Failures),
+				slog.Time("until", *attempts.BlockedUntil),
+				slog.Bool("locked", attempts.Locked),
+			)
+		}
+	}
+
+	return nil
+}
+
+// releaseLoginAttempt Drops the login or MFA counter after a successful check and uncounts the attempt
+// on the ip counter, which is kept so that logins to an own account don't reset guessing from the same ip
+func (oc OwnerCtl) releaseLoginAttempt(ctx context.Context, log *slog.Logger, keys []models.LoginAttemptKey) {
+	for _, key := range keys {
+		var err error
+		if key.Kind != models.LoginAttemptIP {
+			_, err = oc.loginAttempts.ResetLoginAttempts(ctx, key)
+		} else {
+			err = oc.loginAttempts.ReleaseLoginAttempt(ctx, key, oc.loginPolicy(key).Block)
+		}
+		if err != nil {
+			log.Error("failed to release login attempt", sl.Err(err), slog.String("kind", key.Kind))
+		}
+	}
+}
+
+func (oc OwnerCtl) loginPolicy(key models.LoginAttemptKey) loginlimit.Policy {
+	if key.Kind == models.LoginAttemptIP {
+		return oc.cfg.IPLoginLimit
+	}
+	return oc.cfg.LoginLimit
+}
@@ -0,0 +1,482 @@
+package ownerCtl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/totp"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const (
+	// maxMFAAttempts Codes checked for one challenge, then the login has to start over.
+	// Wrong codes of all challenges and sessions of an owner are also limited by its MFA counter
+	maxMFAAttempts = 5
+
+	recoveryCodesCount = 10
+	// recoveryCodeLen Characters of a recovery code, it is shown split in two halves by a dash
+	recoveryCodeLen = 10
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// EnrollTOTP Generates a pending TOTP secret of the token owner with a new set of
+// recovery codes. The secret is asked for on login only after ConfirmTOTP
+func (oc OwnerCtl) EnrollTOTP(ctx context.Context, accessToken string) (models.TOTPEnrollment, error) {
+	const op = "ownerCtl.EnrollTOTP"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("enroll totp")
+
+	if oc.secrets == nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrMFADisabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := oc.secrets.Seal([]byte(secret))
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: failed to encrypt secret %w", op, err)
+	}
+
+	codes, codeHashes, err := oc.newRecoveryCodes()
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.mfaProvider.SaveTOTP(ctx, claims.Uid, sealed, codeHashes); err != nil {
+		if errors.Is(err, storage.ErrTOTPConfirmed) {
+			return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+		}
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrolled")
+
+	return models.TOTPEnrollment{
+		URI:           totp.URI(oc.cfg.MFAIssuer, claims.Login, secret),
+		Secret:        secret,
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmTOTP Enables the pending TOTP secret of the token owner with a code generated by it
+func (oc OwnerCtl) ConfirmTOTP(ctx context.Context, accessToken string, code string) error {
+	const op = "ownerCtl.ConfirmTOTP"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("confirm totp")
+
+	factor, err := oc.getTOTP(ctx, claims.Uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if factor.ConfirmedAt != nil {
+		return fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+	}
+
+	err = oc.countMFACode(ctx, log, claims.Uid, func() error {
+		return oc.checkTOTPCode(ctx, factor, code)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp confirmed")
+
+	return nil
+}
+
+// DisableTOTP Deletes the TOTP secret of the token owner, an enabled one needs
+// a valid TOTP or recovery code
+func (oc OwnerCtl) DisableTOTP(ctx context.Context, accessToken string, code string) error {
+	const op = "ownerCtl.DisableTOTP"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("disable totp")
+
+	factor, err := oc.getTOTP(ctx, claims.Uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if factor.ConfirmedAt != nil {
+		if err = oc.checkMFACode(ctx, log, factor, code); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = oc.mfaProvider.DeleteTOTP(ctx, claims.Uid); err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrTOTPNotEnabled)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp disabled")
+
+	return nil
+}
+
+// VerifyMFA Exchanges the challenge issued by LoginOwner and a valid TOTP or recovery code for tokens
+func (oc OwnerCtl) VerifyMFA(
+	ctx context.Context, mfaToken string, code string, client models.ClientInfo,
+) (models.Tokens, error) {
+	const op = "ownerCtl.VerifyMFA"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("verify mfa")
+
+	challenge, err := oc.mfaProvider.GetMFAChallenge(ctx, hashOpaqueToken(mfaToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: failed get mfa challenge %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", challenge.OwnerId))
+
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return models.Tokens{}, fmt.Errorf("%s: %w: mfa challenge is no longer valid", op, ErrInvalidToken)
+	}
+
+	// The attempt is counted before the code is checked, so concurrent guesses can't pass the limit
+	attempts, err := oc.mfaProvider.ReserveMFAChallengeAttempt(ctx, challenge.Id, maxMFAAttempts)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeUsed) {
+			return models.Tokens{}, fmt.Errorf("%s: %w: mfa challenge is used up", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: failed count attempt %w", op, err)
+	}
+
+	factor, err := oc.getTOTP(ctx, challenge.OwnerId)
+	if err != nil {
+		if errors.Is(err, ErrTOTPNotEnabled) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.checkMFACode(ctx, log, factor, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			log.Warn("invalid mfa code", slog.Int("attempts", attempts))
+		}
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.mfaProvider.UseMFAChallenge(ctx, challenge.Id); err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeUsed) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: failed use mfa challenge %w", op, err)
+	}
+
+	dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: challenge.OwnerId})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+
+	app, err := oc.appProvider.GetApp(ctx, models.AppKey{Id: challenge.AppId})
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: failed get app %w", op, err)
+	}
+
+	org, err := oc.orgMembership(ctx, dbOwner.Id(), challenge.OrgId)
+	if err != nil {
+		if errors.Is(err, ErrNotOrgMember) {
+			return models.Tokens{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidToken, err)
+		}
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := oc.startSession(ctx, dbOwner, app, org, client)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("owner logged in successfully")
+
+	return tokens, nil
+}
+
+// RegenerateRecoveryCodes Replaces the recovery codes of the token owner,
+// it needs a valid TOTP or recovery code
+func (oc OwnerCtl) RegenerateRecoveryCodes(ctx context.Context, accessToken string, code string) ([]string, error) {
+	const op = "ownerCtl.RegenerateRecoveryCodes"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("regenerate recovery codes")
+
+	factor, err := oc.getTOTP(ctx, claims.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if factor.ConfirmedAt == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrTOTPNotEnabled)
+	}
+
+	if err = oc.checkMFACode(ctx, log, factor, code); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	codes, codeHashes, err := oc.newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.mfaProvider.ReplaceRecoveryCodes(ctx, claims.Uid, codeHashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("recovery codes regenerated")
+
+	return codes, nil
+}
+
+// startMFA Issues a challenge when the owner has an enabled second factor,
+// returns an empty token otherwise. The challenge keeps the organization to log in to
+func (oc OwnerCtl) startMFA(ctx context.Context, owner models.Owner, app models.App, orgId int64) (string, error) {
+	factor, err := oc.getTOTP(ctx, owner.Id())
+	if err != nil {
+		if errors.Is(err, ErrTOTPNotEnabled) {
+			return "", nil
+		}
+		return "", err
+	}
+	if factor.ConfirmedAt == nil {
+		return "", nil
+	}
+
+	mfaToken, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mfa token %w", err)
+	}
+
+	if err = oc.mfaProvider.SaveMFAChallenge(ctx, models.MFAChallenge{
+		TokenHash: hashOpaqueToken(mfaToken),
+		OwnerId:   owner.Id(),
+		AppId:     app.Id(),
+		OrgId:     orgId,
+		ExpiresAt: time.Now().Add(oc.cfg.MFAChallengeTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to save mfa challenge %w", err)
+	}
+
+	return mfaToken, nil
+}
+
+func (oc OwnerCtl) getTOTP(ctx context.Context, ownerId int64) (models.TOTP, error) {
+	factor, err := oc.mfaProvider.GetTOTP(ctx, ownerId)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return models.TOTP{}, ErrTOTPNotEnabled
+		}
+		return models.TOTP{}, fmt.Errorf("failed get totp %w", err)
+	}
+	return factor, nil
+}
+
+// loadMFAState Sets whether the owner has an enabled second factor
+// and how many recovery codes are left
+func (oc OwnerCtl) loadMFAState(ctx context.Context, owner *models.Owner) error {
+	factor, err := oc.getTOTP(ctx, owner.Id())
+	if err != nil {
+		if errors.Is(err, ErrTOTPNotEnabled) {
+			owner.SetMFA(false, 0)
+			return nil
+		}
+		return err
+	}
+	if factor.ConfirmedAt == nil {
+		owner.SetMFA(false, 0)
+		return nil
+	}
+
+	codes, err := oc.mfaProvider.GetRecoveryCodes(ctx, owner.Id())
+	if err != nil {
+		return fmt.Errorf("failed get recovery codes %w", err)
+	}
+
+	owner.SetMFA(true, len(codes))
+
+	return nil
+}
+
+// checkMFACode Accepts a recovery code in place of a TOTP code, they are told apart by length
+func (oc OwnerCtl) checkMFACode(ctx context.Context, log *slog.Logger, factor models.TOTP, code string) error {
+	return oc.countMFACode(ctx, log, factor.OwnerId, func() error {
+		if recoveryCode := normalizeRecoveryCode(code); len(recoveryCode) == recoveryCodeLen {
+			return oc.useRecoveryCode(ctx, factor.OwnerId, recoveryCode)
+		}
+		return oc.checkTOTPCode(ctx, factor, code)
+	})
+}
+
+// countMFACode Counts the check as a failure on the MFA counter of the owner before the code is checked,
+// the counter backs off and locks like the login counter. A valid code drops the counter
+func (oc OwnerCtl) countMFACode(ctx context.Context, log *slog.Logger, ownerId int64, check func() error) error {
+	keys := []models.LoginAttemptKey{mfaAttemptKey(ownerId)}
+
+	if err := oc.reserveLoginAttempt(ctx, log, keys); err != nil {
+		return err
+	}
+	if err := check(); err != nil {
+		return err
+	}
+
+	oc.releaseLoginAttempt(ctx, log, keys)
+
+	return nil
+}
+
+// mfaAttemptKey Counts the wrong codes of the second factor of the owner
+func mfaAttemptKey(ownerId int64) models.LoginAttemptKey {
+	return models.LoginAttemptKey{Kind: models.LoginAttemptMFA, Subject: strconv.FormatInt(ownerId, 10)}
+}
+
+// useRecoveryCode Burns the matching unused recovery code of the owner
+func (oc OwnerCtl) useRecoveryCode(ctx context.Context, ownerId int64, code string) error {
+	codes, err := oc.mfaProvider.GetRecoveryCodes(ctx, ownerId)
+	if err != nil {
+		return fmt.Errorf("failed get recovery codes %w", err)
+	}
+
+	for _, stored := range codes {
+		if oc.passwords.Verify(stored.CodeHash, code) != nil {
+			continue
+		}
+
+		if err = oc.mfaProvider.UseRecoveryCode(ctx, stored.Id); err != nil {
+			if errors.Is(err, storage.ErrRecoveryCodeUsed) {
+				return ErrInvalidMFACode
+			}
+			return fmt.Errorf("failed use recovery code %w", err)
+		}
+
+		oc.log.Warn("recovery code used",
+			slog.Int64("uid", ownerId),
+			slog.Int("recovery_codes_left", len(codes)-1),
+		)
+
+		return nil
+	}
+
+	return ErrInvalidMFACode
+}
+
+// checkTOTPCode Validates the code against the secret and burns its step,
+// so an intercepted code can't be replayed. Confirms a pending secret
+func (oc OwnerCtl) checkTOTPCode(ctx context.Context, factor models.TOTP, code string) error {
+	if oc.secrets == nil {
+		return ErrMFADisabled
+	}
+
+	secret, err := oc.secrets.Open(factor.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret %w", err)
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	if err = oc.mfaProvider.UseTOTPStep(ctx, factor.OwnerId, step); err != nil {
+		if errors.Is(err, storage.ErrTOTPStepUsed) {
+			return ErrInvalidMFACode
+		}
+		return fmt.Errorf("failed use totp step %w", err)
+	}
+
+	return nil
+}
+
+// newRecoveryCodes Returns a new set of recovery codes and their hashes, made like password hashes
+func (oc OwnerCtl) newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([][]byte, 0, recoveryCodesCount)
+
+	buf := make([]byte, recoveryCodeLen*5/8)
+	for i := 0; i < recoveryCodesCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code %w", err)
+		}
+		code := recoveryCodeEncoding.EncodeToString(buf)
+
+		hash, err := oc.passwords.Hash(code)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash recovery code %w", err)
+		}
+
+		codes = append(codes, code[:recoveryCodeLen/2]+"-"+code[recoveryCodeLen/2:])
+		hashes = append(hashes, hash)
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode Drops the dash and spaces an owner may type
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -7,39 +7,291 @@ import (
 	"time"
 
 	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/actiontoken"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/loginlimit"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/mailer"
 )
 
 type OwnerCtl struct {
-	log           *slog.Logger
-	ownerSaver    OwnerSaver
-	ownerProvider OwnerProvider
-	tokenTTL      time.Duration
+	log            *slog.Logger
+	ownerSaver     OwnerSaver
+	ownerProvider  OwnerProvider
+	appProvider    AppProvider
+	tokenProvider  RefreshTokenProvider
+	sessions       SessionProvider
+	mfaProvider    MFAProvider
+	passwordResets PasswordResetProvider
+	loginAttempts  LoginAttemptProvider
+	history        PasswordHistoryProvider
+	roles          RoleProvider
+	orgs           MembershipProvider
+	passwords      PasswordHasher
+	pepper         Pepper
+	secrets        SecretBox
+	mailer         Mailer
+	actionTokens   ActionTokenSigner
+	denylist       TokenDenylist
+	tokens         TokenManager
+	cfg            Config
+	// dummyHash Is checked for unknown logins, so they take as long as logins of owners
+	dummyHash []byte
+}
+
+// Config Settings of the owner flows
+type Config struct {
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
+
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+
+	// RequireVerifiedEmail Rejects login of owners who have not verified their email
+	RequireVerifiedEmail bool
+	VerificationTTL      time.Duration
+	// VerificationURL The verification token is appended to it in emails
+	VerificationURL string
+
+	PasswordResetTTL time.Duration
+	// PasswordResetLimit Resets emailed to an owner within PasswordResetTTL, zero is unlimited
+	PasswordResetLimit int
+	// PasswordResetURL The reset token is appended to it in emails
+	PasswordResetURL string
+
+	// LoginLimit Limits failed logins of a login, IPLoginLimit of a client ip
+	LoginLimit   loginlimit.Policy
+	IPLoginLimit loginlimit.Policy
+
+	// PasswordHistory The number of last passwords, the current one included,
+	// a new password has to differ from. Zero allows any reuse
+	PasswordHistory int
+
+	// ListPageSize The page size of listings when a request does not set it, MaxListPageSize caps it
+	ListPageSize    int
+	MaxListPageSize int
 }
 
 type OwnerSaver interface {
 	SaveOwner(ctx context.Context, owner models.Owner) error
+	SaveOrgOwner(ctx context.Context, owner models.Owner, member models.Membership) (int64, error)
 }
 
 type OwnerProvider interface {
 	GetOwner(ctx context.Context, key models.OwnerKey) (models.Owner, error)
-	UpdateOwner(ctx context.Context, owner models.Owner) error
+	ListOwners(ctx context.Context, q models.OwnerListQuery) ([]models.Owner, error)
+	SearchOwners(ctx context.Context, q models.OwnerSearch) ([]models.OwnerMatch, error)
+	UpdateOwner(ctx context.Context, owner models.Owner, fields []string, keepHistory int) error
 	DeleteOwner(ctx context.Context, key models.OwnerKey) error
+	VerifyOwnerEmail(ctx context.Context, id int64, email string) error
+	UpdateOwnerPassword(ctx context.Context, id int64, passHash []byte, pepperVersion int, keepHistory int) error
+	RehashOwnerPassword(ctx context.Context, id int64, oldHash []byte, newHash []byte, pepperVersion int) error
+}
+
+type AppProvider interface {
+	GetApp(ctx context.Context, key models.AppKey) (models.App, error)
+}
+
+type RefreshTokenProvider interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash []byte) (models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, id int64) error
+}
+
+type SessionProvider interface {
+	SaveSession(ctx context.Context, session models.Session) error
+	GetSession(ctx context.Context, id string) (models.Session, error)
+	ListSessions(ctx context.Context, ownerId int64) ([]models.Session, error)
+	TouchSession(ctx context.Context, id string, client models.ClientInfo) error
+	RevokeSession(ctx context.Context, id string) error
+	RevokeOwnerSessions(ctx context.Context, ownerId int64, exceptId string) error
 }
 
+type MFAProvider interface {
+	SaveTOTP(ctx context.Context, ownerId int64, secret []byte, codeHashes [][]byte) error
+	GetTOTP(ctx context.Context, ownerId int64) (models.TOTP, error)
+	UseTOTPStep(ctx context.Context, ownerId int64, step int64) error
+	DeleteTOTP(ctx context.Context, ownerId int64) error
+
+	ReplaceRecoveryCodes(ctx context.Context, ownerId int64, codeHashes [][]byte) error
+	GetRecoveryCodes(ctx context.Context, ownerId int64) ([]models.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, id int64) error
+
+	SaveMFAChallenge(ctx context.Context, challenge models.MFAChallenge) error
+	GetMFAChallenge(ctx context.Context, tokenHash []byte) (models.MFAChallenge, error)
+	ReserveMFAChallengeAttempt(ctx context.Context, id int64, maxAttempts int) (int, error)
+	UseMFAChallenge(ctx context.Context, id int64) error
+}
+
+type PasswordResetProvider interface {
+	SavePasswordReset(ctx context.Context, reset models.PasswordReset, since time.Time, limit int) (bool, error)
+	GetPasswordReset(ctx context.Context, tokenHash []byte) (models.PasswordReset, error)
+	ResetOwnerPassword(
+		ctx context.Context, reset models.PasswordReset, passHash []byte, pepperVersion int, keepHistory int,
+	) error
+}
+
+// LoginAttemptProvider Keeps failed login counters shared by every replica
+type LoginAttemptProvider interface {
+	GetLoginAttempts(ctx context.Context, keys []models.LoginAttemptKey) ([]models.LoginAttempts, error)
+	ReserveLoginAttempt(
+		ctx context.Context, key models.LoginAttemptKey, at time.Time, windowStart time.Time,
+		block models.LoginBlockPolicy,
+	) (models.LoginAttempts, bool, error)
+	ReleaseLoginAttempt(ctx context.Context, key models.LoginAttemptKey, block models.LoginBlockPolicy) error
+	ResetLoginAttempts(ctx context.Context, key models.LoginAttemptKey) (bool, error)
+	DeleteExpiredLoginAttempts(ctx context.Context, kind string, failedBefore time.Time, at time.Time) (int64, error)
+}
+
+// PasswordHistoryProvider Keeps the replaced password hashes of owners
+type PasswordHistoryProvider interface {
+	GetPasswordHistory(ctx context.Context, ownerId int64, limit int) ([]models.PasswordHistoryEntry, error)
+}
+
+// RoleProvider Keeps roles with their permissions and the roles assigned to owners
+type RoleProvider interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	GetOwnerRoles(ctx context.Context, ownerId int64) ([]models.Role, error)
+	AssignRole(ctx context.Context, ownerId int64, role string) (bool, error)
+	RevokeRole(ctx context.Context, ownerId int64, role string) error
+}
+
+// MembershipProvider Keeps the memberships of owners in organizations
+type MembershipProvider interface {
+	GetMembership(ctx context.Context, orgId int64, ownerId int64) (models.Membership, error)
+}
+
+// PasswordHasher Hashes owner passwords. Verify accepts hashes of every supported algorithm,
+// NeedsRehash reports hashes made with outdated settings
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Verify(hash []byte, password string) error
+	NeedsRehash(hash []byte) bool
+}
+
+// Pepper Keys passwords with a server side secret before hashing, the version
+// a hash is made with is stored next to it. Version 0 leaves the password as is
+type Pepper interface {
+	Apply(version int, password string) (string, error)
+	CurrentVersion() int
+}
+
+// SecretBox Encrypts second factor secrets at rest
+type SecretBox interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+// ActionTokenSigner Signs the tokens sent to owners by email
+type ActionTokenSigner interface {
+	Sign(purpose string, claims actiontoken.Claims, ttl time.Duration) (string, error)
+	Verify(purpose string, token string) (actiontoken.Claims, error)
+}
+
+type TokenManager interface {
+	NewToken(
+		owner models.Owner, app models.App, org models.Membership, sessionId string, duration time.Duration,
+	) (string, error)
+	ParseToken(token string, appProvider jwt.AppProvider) (jwt.Claims, error)
+	JWKS() jwt.JWKS
+}
+
+type TokenDenylist interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Token type hints accepted by RevokeToken
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAppNotFound        = errors.New("app not found")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrMFADisabled        = errors.New("mfa is not configured")
+	ErrTOTPEnabled        = errors.New("totp already enabled")
+	ErrTOTPNotEnabled     = errors.New("totp not enabled")
+	ErrInvalidMFACode     = errors.New("invalid mfa code")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrLoginThrottled     = errors.New("too many failed logins")
+	ErrOwnerLocked        = errors.New("owner locked")
+	ErrWeakPassword       = errors.New("weak password")
+	ErrPasswordReused     = errors.New("password used recently")
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleNotAssigned    = errors.New("role not assigned")
+	ErrNotOrgMember       = errors.New("owner is not a member of the organization")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+	ErrNothingToUpdate    = errors.New("nothing to update")
+
+	errAppLookup = errors.New("failed to look up token app")
 )
 
-func New(
-	log *slog.Logger,
-	ownerSaver OwnerSaver,
-	ownerProvider OwnerProvider,
-	tokenTTL time.Duration,
-) *OwnerCtl {
-	return &OwnerCtl{
-		log:           log,
-		ownerSaver:    ownerSaver,
-		ownerProvider: ownerProvider,
-		tokenTTL:      tokenTTL,
+// Storage Every role the owner flows need from the database
+type Storage interface {
+	OwnerSaver
+	OwnerProvider
+	AppProvider
+	RefreshTokenProvider
+	SessionProvider
+	MFAProvider
+	PasswordResetProvider
+	LoginAttemptProvider
+	PasswordHistoryProvider
+	RoleProvider
+	MembershipProvider
+}
+
+// Deps The services the owner flows use besides the storage
+type Deps struct {
+	Passwords    PasswordHasher
+	Pepper       Pepper
+	Secrets      SecretBox
+	Mailer       Mailer
+	ActionTokens ActionTokenSigner
+	Denylist     TokenDenylist
+	Tokens       TokenManager
+}
+
+func New(log *slog.Logger, store Storage, deps Deps, cfg Config) *OwnerCtl {
+	oc := &OwnerCtl{
+		log:            log,
+		ownerSaver:     store,
+		ownerProvider:  store,
+		appProvider:    store,
+		tokenProvider:  store,
+		sessions:       store,
+		mfaProvider:    store,
+		passwordResets: store,
+		loginAttempts:  store,
+		history:        store,
+		roles:          store,
+		orgs:           store,
+		passwords:      deps.Passwords,
+		pepper:         deps.Pepper,
+		secrets:        deps.Secrets,
+		mailer:         deps.Mailer,
+		actionTokens:   deps.ActionTokens,
+		denylist:       deps.Denylist,
+		tokens:         deps.Tokens,
+		cfg:            cfg,
+	}
+
+	dummyPassword, err := newOpaqueToken()
+	if err == nil {
+		oc.dummyHash, _, err = oc.getPasswordHash(dummyPassword)
+	}
+	if err != nil {
+		log.Error("failed to hash the password of unknown logins", sl.Err(err))
 	}
+
+	return oc
 }
@@ -0,0 +1,303 @@
+package ownerCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models/validator"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/mailer"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const (
+	passwordResetSubject = "Reset your password"
+
+	// mailTimeout Bounds the work of an email sent after the response is returned
+	mailTimeout = 30 * time.Second
+)
+
+// RequestPasswordReset Emails a reset link to the owner with the login or email.
+// The owner is looked up, the reset is saved and the email is sent in the background for any key,
+// so neither the response nor its time tells whether the owner exists.
+// An owner gets at most PasswordResetLimit links within PasswordResetTTL, further requests are dropped
+func (oc OwnerCtl) RequestPasswordReset(ctx context.Context, key models.OwnerKey) error {
+	const op = "ownerCtl.RequestPasswordReset"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("request password reset")
+
+	go func() {
+		jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+		defer cancel()
+
+		if err := oc.sendPasswordReset(jobCtx, log, key); err != nil {
+			log.Error("failed to send password reset email", sl.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// sendPasswordReset Saves a reset of the owner with the key and emails its link,
+// an unknown owner or an owner past the limit gets nothing
+func (oc OwnerCtl) sendPasswordReset(ctx context.Context, log *slog.Logger, key models.OwnerKey) error {
+	owner, err := oc.ownerProvider.GetOwner(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			log.Info("owner not found, nothing to send")
+			return nil
+		}
+		return fmt.Errorf("failed get owner %w", err)
+	}
+
+	log = log.With(slog.Int64("uid", owner.Id()))
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token %w", err)
+	}
+
+	now := time.Now()
+	saved, err := oc.passwordResets.SavePasswordReset(ctx, models.PasswordReset{
+		TokenHash: hashOpaqueToken(token),
+		OwnerId:   owner.Id(),
+		ExpiresAt: now.Add(oc.cfg.PasswordResetTTL),
+	}, now.Add(-oc.cfg.PasswordResetTTL), oc.cfg.PasswordResetLimit)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		log.Warn("password reset limit reached, nothing to send")
+		return nil
+	}
+
+	if err = oc.mailer.Send(ctx, mailer.Message{
+		To:      owner.Email(),
+		Subject: passwordResetSubject,
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nSet a new password by opening the link below, it is valid for %s.\n\n%s%s\n\n"+
+				"If you did not ask to reset the password, ignore this email.\n",
+			owner.Login(), oc.cfg.PasswordResetTTL, oc.cfg.PasswordResetURL, token,
+		),
+	}); err != nil {
+		return err
+	}
+
+	log.Info("password reset email sent")
+
+	return nil
+}
+
+// ResetPassword Sets the password of the owner the reset token is issued for
+// and revokes every session of the owner
+func (oc OwnerCtl) ResetPassword(ctx context.Context, token string, password string) error {
+	const op = "ownerCtl.ResetPassword"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("reset password")
+
+	reset, err := oc.passwordResets.GetPasswordReset(ctx, hashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrPasswordResetNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: failed get password reset %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", reset.OwnerId))
+
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return fmt.Errorf("%s: %w: reset token is no longer valid", op, ErrInvalidToken)
+	}
+
+	owner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: reset.OwnerId})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+	if err = checkPasswordPolicy(password, owner); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = oc.checkPasswordReuse(ctx, owner, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passwordHash, pepperVersion, err := oc.getPasswordHash(password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.passwordResets.ResetOwnerPassword(ctx, reset, passwordHash, pepperVersion, oc.historyKeep()); err != nil {
+		if errors.Is(err, storage.ErrPasswordResetUsed) || errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.sessions.RevokeOwnerSessions(ctx, reset.OwnerId, ""); err != nil {
+		return fmt.Errorf("%s: failed revoke sessions %w", op, err)
+	}
+
+	log.Info("password reset, sessions revoked")
+
+	return nil
+}
+
+// ChangePassword Sets a new password of the caller after checking the current one.
+// Tokens issued before the change stop verifying and every other session is revoked,
+// the current session gets a new access token by its refresh token
+func (oc OwnerCtl) ChangePassword(
+	ctx context.Context, accessToken string, currentPassword string, newPassword string,
+) error {
+	const op = "ownerCtl.ChangePassword"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("change password")
+
+	owner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: claims.Uid})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: failed get owner %w", op, err)
+	}
+
+	// Wrong current passwords count on the login counter of the owner, like failed logins
+	attemptKeys := []models.LoginAttemptKey{loginAttemptKey(owner.OrgId(), owner.Login())}
+	if err = oc.checkLoginBlocked(ctx, attemptKeys); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = oc.reserveLoginAttempt(ctx, log, attemptKeys); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = oc.verifyPassword(owner, currentPassword); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	oc.releaseLoginAttempt(ctx, log, attemptKeys)
+
+	if err = checkPasswordPolicy(newPassword, owner); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = oc.checkPasswordReuse(ctx, owner, newPassword); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passwordHash, pepperVersion, err := oc.getPasswordHash(newPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.ownerProvider.UpdateOwnerPassword(ctx, claims.Uid, passwordHash, pepperVersion, oc.historyKeep()); err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.sessions.RevokeOwnerSessions(ctx, claims.Uid, claims.Sid); err != nil {
+		return fmt.Errorf("%s: failed revoke sessions %w", op, err)
+	}
+
+	log.Info("password changed, other sessions revoked")
+
+	return nil
+}
+
+// rehashPassword Replaces a hash made with outdated settings or an old pepper after a successful login,
+// a failure is only logged since the old hash keeps verifying
+func (oc OwnerCtl) rehashPassword(ctx context.Context, log *slog.Logger, owner models.Owner, password string) {
+	if !oc.passwords.NeedsRehash(owner.PassHash()) && owner.PepperVersion() == oc.pepper.CurrentVersion() {
+		return
+	}
+
+	passwordHash, pepperVersion, err := oc.getPasswordHash(password)
+	if err == nil {
+		err = oc.ownerProvider.RehashOwnerPassword(ctx, owner.Id(), owner.PassHash(), passwordHash, pepperVersion)
+	}
+	if err != nil {
+		log.Error("failed to rehash password", sl.Err(err))
+		return
+	}
+
+	log.Info("password rehashed")
+}
+
+// checkPasswordReuse Rejects the password of the owner and the ones in its history
+// within the configured number of last passwords
+func (oc OwnerCtl) checkPasswordReuse(ctx context.Context, owner models.Owner, password string) error {
+	if oc.cfg.PasswordHistory <= 0 {
+		return nil
+	}
+
+	if oc.passwordMatches(owner.PassHash(), owner.PepperVersion(), password) {
+		return ErrPasswordReused
+	}
+	if oc.cfg.PasswordHistory == 1 {
+		return nil
+	}
+
+	entries, err := oc.history.GetPasswordHistory(ctx, owner.Id(), oc.cfg.PasswordHistory-1)
+	if err != nil {
+		return fmt.Errorf("failed get password history %w", err)
+	}
+
+	for _, entry := range entries {
+		if oc.passwordMatches(entry.PassHash, entry.PepperVersion, password) {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// historyKeep Returns how many replaced hashes of an owner the history keeps,
+// the current password is the last of the history size
+func (oc OwnerCtl) historyKeep() int {
+	return max(oc.cfg.PasswordHistory-1, 0)
+}
+
+// passwordMatches Reports whether the hash is made of the password, a hash of a retired pepper never matches
+func (oc OwnerCtl) passwordMatches(hash []byte, pepperVersion int, password string) bool {
+	peppered, err := oc.pepper.Apply(pepperVersion, password)
+	if err != nil {
+		return false
+	}
+	return oc.passwords.Verify(hash, peppered) == nil
+}
+
+// checkPasswordPolicy Checks the password against the policy with the logins and the emails of the owners,
+// an updated owner is checked with both its new and its stored values
+func checkPasswordPolicy(password string, owners ...models.Owner) error {
+	personal := make([]string, 0, len(owners)*2)
+	for _, owner := range owners {
+		personal = append(personal, owner.Login(), owner.Email())
+	}
+
+	if err := validator.ValidatePassword(password, personal...); err != nil {
+		return fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+	return nil
+}
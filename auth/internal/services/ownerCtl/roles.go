@@ -0,0 +1,208 @@
+package ownerCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// Authenticate Verifies the access token and returns its owner with the permissions of the roles
+// assigned to the owner. The roles claim of the token is not trusted, roles are looked up
+// on every call, so a changed role applies at once
+func (oc OwnerCtl) Authenticate(ctx context.Context, accessToken string) (models.Caller, error) {
+	const op = "ownerCtl.Authenticate"
+
+	claims, owner, err := oc.verifyAccessTokenOwner(ctx, accessToken)
+	if err != nil {
+		return models.Caller{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := oc.roles.GetOwnerRoles(ctx, claims.Uid)
+	if err != nil {
+		return models.Caller{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	caller := models.Caller{Id: claims.Uid, Roles: roleNames(roles)}
+	// The login of an account of an organization names another owner in calls without an organization
+	if owner.OrgId() == 0 {
+		caller.Login = owner.Login()
+	}
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if !slices.Contains(caller.Permissions, permission) {
+				caller.Permissions = append(caller.Permissions, permission)
+			}
+		}
+	}
+
+	return caller, nil
+}
+
+// AssignRole Assigns the role to the owner with the id or login, assigning it again is not an error
+func (oc OwnerCtl) AssignRole(ctx context.Context, owner models.Owner, role string) error {
+	const op = "ownerCtl.AssignRole"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+		slog.Int("id", int(owner.Id())),
+		slog.String("role", role),
+	)
+
+	log.Info("assign role")
+
+	dbOwner, err := oc.roleOwner(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	assigned, err := oc.roles.AssignRole(ctx, dbOwner.Id(), role)
+	if err != nil {
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+		}
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role assigned", slog.Bool("already_assigned", !assigned))
+
+	return nil
+}
+
+// RevokeRole Removes the role from the owner with the id or login
+func (oc OwnerCtl) RevokeRole(ctx context.Context, owner models.Owner, role string) error {
+	const op = "ownerCtl.RevokeRole"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+		slog.Int("id", int(owner.Id())),
+		slog.String("role", role),
+	)
+
+	log.Info("revoke role")
+
+	dbOwner, err := oc.roleOwner(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = oc.roles.RevokeRole(ctx, dbOwner.Id(), role); err != nil {
+		if errors.Is(err, storage.ErrRoleNotAssigned) {
+			return fmt.Errorf("%s: %w", op, ErrRoleNotAssigned)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role revoked")
+
+	return nil
+}
+
+// ListRoles Returns the roles of the owner with the id or login, or every role without an owner
+func (oc OwnerCtl) ListRoles(ctx context.Context, owner models.Owner) ([]models.Role, error) {
+	const op = "ownerCtl.ListRoles"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+		slog.Int("id", int(owner.Id())),
+	)
+
+	log.Info("list roles")
+
+	if owner.Id() == 0 && owner.Login() == "" {
+		roles, err := oc.roles.ListRoles(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return roles, nil
+	}
+
+	dbOwner, err := oc.roleOwner(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := oc.roles.GetOwnerRoles(ctx, dbOwner.Id())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// BootstrapAdmin Creates the owner with a verified email unless it exists and assigns it the admin role,
+// so that a fresh deployment has someone to assign roles
+func (oc OwnerCtl) BootstrapAdmin(ctx context.Context, owner models.Owner) error {
+	const op = "ownerCtl.BootstrapAdmin"
+
+	log := oc.log.With(
+		slog.String("op", op),
+		slog.String("login", owner.Login()),
+	)
+
+	dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Login: owner.Login()})
+	if errors.Is(err, storage.ErrOwnerNotFound) {
+		if owner.Password() == "" {
+			return fmt.Errorf("%s: admin owner does not exist and has no password to be created with", op)
+		}
+
+		passwordHash, pepperVersion, errGPH := oc.getPasswordHash(owner.Password())
+		if errGPH != nil {
+			return fmt.Errorf("%s: %w", op, errGPH)
+		}
+		owner.SetPassHash(passwordHash)
+		owner.SetPepperVersion(pepperVersion)
+
+		if err = oc.ownerSaver.SaveOwner(ctx, owner); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		dbOwner, err = oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Login: owner.Login()})
+		if err == nil {
+			err = oc.ownerProvider.VerifyOwnerEmail(ctx, dbOwner.Id(), dbOwner.Email())
+		}
+
+		log.Info("admin owner created")
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = oc.roles.AssignRole(ctx, dbOwner.Id(), models.RoleAdmin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("admin role assigned", slog.Int64("uid", dbOwner.Id()))
+
+	return nil
+}
+
+// roleOwner Finds the owner roles are managed for by its id or login
+func (oc OwnerCtl) roleOwner(ctx context.Context, owner models.Owner) (models.Owner, error) {
+	dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: owner.Id(), Login: owner.Login()})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return models.Owner{}, ErrInvalidCredentials
+		}
+		return models.Owner{}, fmt.Errorf("failed get owner %w", err)
+	}
+	return dbOwner, nil
+}
+
+func roleNames(roles []models.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package ownerCtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// ListSessions Returns the active sessions of the token owner and the id of the token session
+func (oc OwnerCtl) ListSessions(ctx context.Context, accessToken string) ([]models.Session, string, error) {
+	const op = "ownerCtl.ListSessions"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("list sessions", slog.Int64("uid", claims.Uid))
+
+	sessions, err := oc.sessions.ListSessions(ctx, claims.Uid)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, claims.Sid, nil
+}
+
+// RevokeSession Revokes a session of the token owner, sessions of other owners are not found
+func (oc OwnerCtl) RevokeSession(ctx context.Context, accessToken string, sessionId string) error {
+	const op = "ownerCtl.RevokeSession"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("revoke session")
+
+	session, err := oc.sessions.GetSession(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if session.OwnerId != claims.Uid {
+		return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+	}
+
+	if err = oc.sessions.RevokeSession(ctx, session.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("session revoked")
+
+	return nil
+}
+
+// RevokeAllSessions Revokes every session of the token owner, keeping the token session if asked
+func (oc OwnerCtl) RevokeAllSessions(ctx context.Context, accessToken string, keepCurrent bool) error {
+	const op = "ownerCtl.RevokeAllSessions"
+
+	log := oc.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := oc.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.Uid))
+
+	log.Info("revoke all sessions", slog.Bool("keep_current", keepCurrent))
+
+	exceptId := ""
+	if keepCurrent {
+		exceptId = claims.Sid
+	}
+
+	if err = oc.sessions.RevokeOwnerSessions(ctx, claims.Uid, exceptId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("sessions revoked")
+
+	return nil
+}
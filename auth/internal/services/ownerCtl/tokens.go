@@ -0,0 +1,269 @@
+package ownerCtl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/sl"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const opaqueTokenLen = 32
+
+// startSession Saves a new session of the owner logged in to the organization
+// of the membership, if any, and issues its first tokens
+func (oc OwnerCtl) startSession(
+	ctx context.Context, owner models.Owner, app models.App, org models.Membership, client models.ClientInfo,
+) (models.Tokens, error) {
+	sessionId, err := newOpaqueToken()
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("failed to generate session id %w", err)
+	}
+
+	if err = oc.sessions.SaveSession(ctx, models.Session{
+		Id:      sessionId,
+		OwnerId: owner.Id(),
+		AppId:   app.Id(),
+		OrgId:   org.OrgId,
+		Client:  client,
+	}); err != nil {
+		return models.Tokens{}, fmt.Errorf("failed to save session %w", err)
+	}
+
+	return oc.issueTokens(ctx, owner, app, org, sessionId)
+}
+
+// issueTokens Issues an access token with the current roles of the owner and a refresh token
+// for the session, the session id is the refresh token family
+func (oc OwnerCtl) issueTokens(
+	ctx context.Context, owner models.Owner, app models.App, org models.Membership, sessionId string,
+) (models.Tokens, error) {
+	roles, err := oc.roles.GetOwnerRoles(ctx, owner.Id())
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("failed to get owner roles %w", err)
+	}
+	owner.SetRoles(roleNames(roles))
+
+	accessToken, err := oc.tokens.NewToken(owner, app, org, sessionId, oc.cfg.TokenTTL)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("failed to generate token %w", err)
+	}
+
+	refreshToken, errNT := newOpaqueToken()
+	if errNT != nil {
+		return models.Tokens{}, fmt.Errorf("failed to generate refresh token %w", errNT)
+	}
+
+	if err = oc.tokenProvider.SaveRefreshToken(ctx, models.RefreshToken{
+		TokenHash: hashOpaqueToken(refreshToken),
+		FamilyId:  sessionId,
+		OwnerId:   owner.Id(),
+		AppId:     app.Id(),
+		ExpiresAt: time.Now().Add(oc.cfg.RefreshTokenTTL),
+	}); err != nil {
+		return models.Tokens{}, fmt.Errorf("failed to save refresh token %w", err)
+	}
+
+	return models.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// loginOwner Finds the owner logging in. A login to an organization names its own account first
+// and then an owner without an organization, reports false when neither exists
+func (oc OwnerCtl) loginOwner(ctx context.Context, owner models.Owner, orgId int64) (models.Owner, bool, error) {
+	if orgId != 0 {
+		dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Login: owner.Login(), OrgId: orgId})
+		if err == nil {
+			return dbOwner, true, nil
+		}
+		if !errors.Is(err, storage.ErrOwnerNotFound) {
+			return models.Owner{}, false, err
+		}
+	}
+
+	dbOwner, err := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: owner.Id(), Login: owner.Login()})
+	if err != nil {
+		if errors.Is(err, storage.ErrOwnerNotFound) {
+			return models.Owner{}, false, nil
+		}
+		return models.Owner{}, false, err
+	}
+
+	return dbOwner, true, nil
+}
+
+// orgMembership Returns the accepted membership of the owner in the organization to log in to,
+// a zero organization id means no organization and gives an empty membership
+func (oc OwnerCtl) orgMembership(ctx context.Context, ownerId int64, orgId int64) (models.Membership, error) {
+	if orgId == 0 {
+		return models.Membership{}, nil
+	}
+
+	member, err := oc.orgs.GetMembership(ctx, orgId, ownerId)
+	if err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return models.Membership{}, ErrNotOrgMember
+		}
+		return models.Membership{}, fmt.Errorf("failed get membership %w", err)
+	}
+	// An invited owner logs in to the organization once it accepts the invitation
+	if member.Pending {
+		return models.Membership{}, ErrNotOrgMember
+	}
+
+	return member, nil
+}
+
+// revokeReusedFamily A used refresh token presented again means it leaked,
+// so the session with every token of its family is revoked
+func (oc OwnerCtl) revokeReusedFamily(
+	ctx context.Context, log *slog.Logger, op string, token models.RefreshToken,
+) error {
+	log.Warn("refresh token reuse detected, revoking session")
+
+	if err := oc.sessions.RevokeSession(ctx, token.FamilyId); err != nil {
+		log.Error("failed to revoke refresh token family", sl.Err(err))
+		return fmt.Errorf("%s: failed revoke family %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
+}
+
+// tokenApp Looks up the app a token is issued for, an unknown app makes the token invalid
+func (oc OwnerCtl) tokenApp(ctx context.Context) jwt.AppProvider {
+	return func(appId int32) (models.App, error) {
+		app, err := oc.appProvider.GetApp(ctx, models.AppKey{Id: appId})
+		if err != nil && !errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, fmt.Errorf("%w: %w", errAppLookup, err)
+		}
+		return app, err
+	}
+}
+
+// verifyAccessToken Checks the token signature, expiry and app,
+// and that neither the token nor its session is revoked and its owner is not deleted
+func (oc OwnerCtl) verifyAccessToken(ctx context.Context, token string) (jwt.Claims, error) {
+	claims, _, err := oc.verifyAccessTokenOwner(ctx, token)
+	return claims, err
+}
+
+// verifyAccessTokenOwner Verifies the token as verifyAccessToken and returns its owner too
+func (oc OwnerCtl) verifyAccessTokenOwner(ctx context.Context, token string) (jwt.Claims, models.Owner, error) {
+	claims, err := oc.tokens.ParseToken(token, oc.tokenApp(ctx))
+	if err != nil {
+		if errors.Is(err, errAppLookup) {
+			return jwt.Claims{}, models.Owner{}, err
+		}
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	revoked, errIR := oc.denylist.IsRevoked(ctx, claims.ID)
+	if errIR != nil {
+		return jwt.Claims{}, models.Owner{}, errIR
+	}
+	if revoked {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: token revoked", ErrInvalidToken)
+	}
+
+	// Every issued token belongs to a session, a token without one or with a session
+	// of another owner is not issued by the server
+	if claims.Sid == "" {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: missing session", ErrInvalidToken)
+	}
+	session, errGS := oc.sessions.GetSession(ctx, claims.Sid)
+	if errGS != nil && !errors.Is(errGS, storage.ErrSessionNotFound) {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("failed get session %w", errGS)
+	}
+	if errGS != nil || session.RevokedAt != nil {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: session revoked", ErrInvalidToken)
+	}
+	if session.OwnerId != claims.Uid {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: session of another owner", ErrInvalidToken)
+	}
+
+	owner, errGO := oc.ownerProvider.GetOwner(ctx, models.OwnerKey{Id: claims.Uid})
+	if errGO != nil {
+		if errors.Is(errGO, storage.ErrOwnerNotFound) {
+			return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: owner deleted", ErrInvalidToken)
+		}
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("failed get owner %w", errGO)
+	}
+
+	if issuedBeforePasswordChange(claims, owner) {
+		return jwt.Claims{}, models.Owner{}, fmt.Errorf("%w: password changed", ErrInvalidToken)
+	}
+
+	return claims, owner, nil
+}
+
+// issuedBeforePasswordChange The iat claim has a second precision,
+// so a token issued within the second of the change is still accepted
+func issuedBeforePasswordChange(claims jwt.Claims, owner models.Owner) bool {
+	changedAt := owner.PasswordChangedAt()
+	if changedAt.IsZero() {
+		return false
+	}
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return claims.IssuedAt.Time.Before(changedAt.Truncate(time.Second))
+}
+
+// revokeAccessToken Reports false when the token is not a valid access token
+func (oc OwnerCtl) revokeAccessToken(ctx context.Context, token string) (bool, error) {
+	claims, err := oc.tokens.ParseToken(token, oc.tokenApp(ctx))
+	if err != nil {
+		if errors.Is(err, errAppLookup) {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if err = oc.denylist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// revokeRefreshToken Revokes the session of the refresh token,
+// ownerId restricts it to tokens of that owner when not zero
+func (oc OwnerCtl) revokeRefreshToken(ctx context.Context, token string, ownerId int64) error {
+	stored, err := oc.tokenProvider.GetRefreshToken(ctx, hashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("failed get refresh token %w", err)
+	}
+
+	if ownerId != 0 && stored.OwnerId != ownerId {
+		return fmt.Errorf("%w: refresh token of another owner", ErrInvalidToken)
+	}
+
+	if err = oc.sessions.RevokeSession(ctx, stored.FamilyId); err != nil {
+		return fmt.Errorf("failed revoke session %w", err)
+	}
+
+	return nil
+}
+
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, opaqueTokenLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashOpaqueToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
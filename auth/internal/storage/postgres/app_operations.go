@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+func (s *Storage) SaveApp(ctx context.Context, app models.App) (int32, error) {
+	const op = "postgres.saveApp"
+
+	queryInsert := `
+		INSERT INTO apps (name, secret)
+		VALUES ($1, $2)
+		RETURNING id
+    `
+
+	var id int32
+	err := s.pool.QueryRow(ctx, queryInsert, app.Name(), app.Secret()).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: failed to save app: %w", op, storage.ErrAppExists)
+		}
+		return 0, fmt.Errorf("%s: failed to save app: %w", op, err)
+	}
+
+	s.log.Info("App created successfully",
+		slog.Int("id", int(id)),
+		slog.String("name", app.Name()),
+	)
+
+	return id, nil
+}
+
+func (s *Storage) GetApp(ctx context.Context, key models.AppKey) (models.App, error) {
+	if key.Id != 0 {
+		return s.getApp(ctx, "id", key.Id)
+	} else if key.Name != "" {
+		return s.getApp(ctx, "name", key.Name)
+	}
+	return models.App{}, fmt.Errorf("unattainable error: either id or name must be provided")
+}
+
+func (s *Storage) getApp(ctx context.Context, column string, value any) (models.App, error) {
+	var app models.App
+	query := fmt.Sprintf(`
+		SELECT id, name, secret
+		FROM apps
+		WHERE %s=$1
+	`, column)
+
+	var id int32
+	var name, secret string
+
+	err := s.pool.QueryRow(ctx, query, value).Scan(&id, &name, &secret)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%w with %s %v", storage.ErrAppNotFound, column, value)
+		}
+		return models.App{}, fmt.Errorf("failed to get app by %s: %w", column, err)
+	}
+	_ = app.SetId(id)
+	_ = app.SetName(name)
+	app.SetSecret(secret)
+
+	s.log.Info("App retrieved successfully",
+		slog.Int("id", int(app.Id())),
+		slog.String("name", app.Name()),
+	)
+
+	return app, nil
+}
+
+func (s *Storage) ListApps(ctx context.Context) ([]models.App, error) {
+	query := `
+		SELECT id, name
+		FROM apps
+		ORDER BY id
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+	defer rows.Close()
+
+	apps := make([]models.App, 0)
+	for rows.Next() {
+		var id int32
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan app: %w", err)
+		}
+
+		var app models.App
+		_ = app.SetId(id)
+		_ = app.SetName(name)
+		apps = append(apps, app)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	return apps, nil
+}
+
+func (s *Storage) UpdateApp(ctx context.Context, app models.App) error {
+	query := `UPDATE apps SET name=$1 WHERE id=$2`
+
+	result, err := s.pool.Exec(ctx, query, app.Name(), app.Id())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to update app: %w", storage.ErrAppExists)
+		}
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id %d", storage.ErrAppNotFound, app.Id())
+	}
+
+	s.log.Info("App updated successfully", slog.Int("id", int(app.Id())))
+
+	return nil
+}
+
+func (s *Storage) UpdateAppSecret(ctx context.Context, id int32, secret string) error {
+	query := `UPDATE apps SET secret=$1 WHERE id=$2`
+
+	result, err := s.pool.Exec(ctx, query, secret, id)
+	if err != nil {
+		return fmt.Errorf("failed to update app secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id %d", storage.ErrAppNotFound, id)
+	}
+
+	s.log.Info("App secret rotated successfully", slog.Int("id", int(id)))
+
+	return nil
+}
+
+func (s *Storage) DeleteApp(ctx context.Context, key models.AppKey) error {
+	if key.Id != 0 {
+		return s.deleteApp(ctx, "id", key.Id)
+	} else if key.Name != "" {
+		return s.deleteApp(ctx, "name", key.Name)
+	}
+	return fmt.Errorf("either id or name must be provided")
+}
+
+func (s *Storage) deleteApp(ctx context.Context, column string, value any) error {
+	query := fmt.Sprintf(`DELETE FROM apps WHERE %s=$1`, column)
+	commandTag, err := s.pool.Exec(ctx, query, value)
+	if err != nil {
+		return fmt.Errorf("failed to delete app by %s: %w", column, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return fmt.Errorf("%w with %s %v", storage.ErrAppNotFound, column, value)
+	}
+
+	s.log.Info("App deleted successfully", slog.String(column, fmt.Sprint(value)))
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -19,11 +20,11 @@ func (s *Storage) SaveOwner(ctx context.Context, owner models.Owner) error {
 	const op = "postgres.saveOwner"
 
 	queryInsert := `
-		INSERT INTO owners (email, login, password_hash)
-		VALUES ($1, $2, $3)
+		INSERT INTO owners (email, login, password_hash, pepper_version)
+		VALUES ($1, $2, $3, $4)
     `
 
-	_, err := s.pool.Exec(ctx, queryInsert, owner.Email(), owner.Login(), owner.PassHash())
+	_, err := s.pool.Exec(ctx, queryInsert, owner.Email(), owner.Login(), owner.PassHash(), owner.PepperVersion())
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -46,23 +47,35 @@ func (s *Storage) GetOwner(ctx context.Context, key models.OwnerKey) (models.Own
 	if key.Id != 0 {
 		return s.getOwnerById(ctx, key.Id)
 	} else if key.Login != "" {
-		return s.getOwnerByLogin(ctx, key.Login)
+		return s.getOwnerByLogin(ctx, key.Login, key.OrgId)
+	} else if key.Email != "" {
+		return s.getOwnerByEmail(ctx, key.Email, key.OrgId)
 	}
-	return models.Owner{}, fmt.Errorf("unattainable error: either id or login must be provided")
+	return models.Owner{}, fmt.Errorf("unattainable error: either id, login or email must be provided")
 }
 
 func (s *Storage) getOwnerById(ctx context.Context, searchId int64) (models.Owner, error) {
 	var owner models.Owner
 	query := `
-		SELECT id, email, login, password_hash 
+		SELECT id, email, login, password_hash, pepper_version, email_verified, password_changed_at, created_at,
+			display_name, COALESCE(org_id, 0)
 		FROM owners
 		WHERE id=$1
 	`
 	var id int64
 	var email, newLogin string
 	var passHash []byte
+	var pepperVersion int
+	var emailVerified bool
+	var passwordChangedAt *time.Time
+	var createdAt time.Time
+	var displayName *string
+	var orgId int64
 
-	err := s.pool.QueryRow(ctx, query, searchId).Scan(&id, &email, &newLogin, &passHash)
+	err := s.pool.QueryRow(ctx, query, searchId).Scan(
+		&id, &email, &newLogin, &passHash, &pepperVersion, &emailVerified, &passwordChangedAt, &createdAt,
+		&displayName, &orgId,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Owner{}, fmt.Errorf("%w with id %d ", storage.ErrOwnerNotFound, searchId)
@@ -73,6 +86,16 @@ func (s *Storage) getOwnerById(ctx context.Context, searchId int64) (models.Owne
 	_ = owner.SetEmail(email)
 	_ = owner.SetLogin(newLogin)
 	owner.SetPassHash(passHash)
+	owner.SetPepperVersion(pepperVersion)
+	owner.SetEmailVerified(emailVerified)
+	owner.SetCreatedAt(createdAt)
+	if passwordChangedAt != nil {
+		owner.SetPasswordChangedAt(*passwordChangedAt)
+	}
+	if displayName != nil {
+		_ = owner.SetDisplayName(*displayName)
+	}
+	owner.SetOrgId(orgId)
 
 	s.log.Info("Owner retrieved successfully by id",
 		slog.Int64("id", owner.Id()),
@@ -83,19 +106,30 @@ func (s *Storage) getOwnerById(ctx context.Context, searchId int64) (models.Owne
 	return owner, nil
 }
 
-func (s *Storage) getOwnerByLogin(ctx context.Context, searchLogin string) (models.Owner, error) {
+// getOwnerByLogin Looks the login up among the accounts of the organization, zero for owners without one
+func (s *Storage) getOwnerByLogin(ctx context.Context, searchLogin string, searchOrgId int64) (models.Owner, error) {
 	var owner models.Owner
 	query := `
-		SELECT id, email, login, password_hash
+		SELECT id, email, login, password_hash, pepper_version, email_verified, password_changed_at, created_at,
+			display_name, COALESCE(org_id, 0)
 		FROM owners WHERE
-		login=$1
+		login=$1 AND COALESCE(org_id, 0)=$2
 	`
 	// The costs of using getters and setters
 	var id int64
 	var email, login string
 	var passHash []byte
+	var pepperVersion int
+	var emailVerified bool
+	var passwordChangedAt *time.Time
+	var createdAt time.Time
+	var displayName *string
+	var orgId int64
 
-	err := s.pool.QueryRow(ctx, query, searchLogin).Scan(&id, &email, &login, &passHash)
+	err := s.pool.QueryRow(ctx, query, searchLogin, searchOrgId).Scan(
+		&id, &email, &login, &passHash, &pepperVersion, &emailVerified, &passwordChangedAt, &createdAt,
+		&displayName, &orgId,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Owner{}, fmt.Errorf("%w with login %s", storage.ErrOwnerNotFound, searchLogin)
@@ -106,6 +140,16 @@ func (s *Storage) getOwnerByLogin(ctx context.Context, searchLogin string) (mode
 	_ = owner.SetEmail(email)
 	_ = owner.SetLogin(login)
 	owner.SetPassHash(passHash)
+	owner.SetPepperVersion(pepperVersion)
+	owner.SetEmailVerified(emailVerified)
+	owner.SetCreatedAt(createdAt)
+	if passwordChangedAt != nil {
+		owner.SetPasswordChangedAt(*passwordChangedAt)
+	}
+	if displayName != nil {
+		_ = owner.SetDisplayName(*displayName)
+	}
+	owner.SetOrgId(orgId)
 
 	s.log.Info("Owner retrieved successfully by login",
 		slog.Int64("id", owner.Id()),
@@ -116,25 +160,96 @@ func (s *Storage) getOwnerByLogin(ctx context.Context, searchLogin string) (mode
 	return owner, nil
 }
 
-func (s *Storage) UpdateOwner(ctx context.Context, owner models.Owner) error {
-	setClauses := make([]string, 0)
-	args := make([]interface{}, 0)
-	argId := 1
+// getOwnerByEmail Looks the email up among the accounts of the organization, zero for owners without one
+func (s *Storage) getOwnerByEmail(ctx context.Context, searchEmail string, searchOrgId int64) (models.Owner, error) {
+	var owner models.Owner
+	query := `
+		SELECT id, email, login, password_hash, pepper_version, email_verified, password_changed_at, created_at,
+			display_name, COALESCE(org_id, 0)
+		FROM owners WHERE
+		email=$1 AND COALESCE(org_id, 0)=$2
+	`
+	var id int64
+	var email, login string
+	var passHash []byte
+	var pepperVersion int
+	var emailVerified bool
+	var passwordChangedAt *time.Time
+	var createdAt time.Time
+	var displayName *string
+	var orgId int64
 
-	if owner.Email() != "" {
-		setClauses = append(setClauses, fmt.Sprintf("email=$%d", argId))
-		args = append(args, owner.Email())
-		argId++
+	err := s.pool.QueryRow(ctx, query, searchEmail, searchOrgId).Scan(
+		&id, &email, &login, &passHash, &pepperVersion, &emailVerified, &passwordChangedAt, &createdAt,
+		&displayName, &orgId,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Owner{}, fmt.Errorf("%w with email %s", storage.ErrOwnerNotFound, searchEmail)
+		}
+		return models.Owner{}, fmt.Errorf("failed to get owner by email: %w", err)
+	}
+	_ = owner.SetId(id)
+	_ = owner.SetEmail(email)
+	_ = owner.SetLogin(login)
+	owner.SetPassHash(passHash)
+	owner.SetPepperVersion(pepperVersion)
+	owner.SetEmailVerified(emailVerified)
+	owner.SetCreatedAt(createdAt)
+	if passwordChangedAt != nil {
+		owner.SetPasswordChangedAt(*passwordChangedAt)
 	}
-	if owner.Login() != "" {
-		setClauses = append(setClauses, fmt.Sprintf("login=$%d", argId))
-		args = append(args, owner.Login())
-		argId++
+	if displayName != nil {
+		_ = owner.SetDisplayName(*displayName)
+	}
+	owner.SetOrgId(orgId)
+
+	s.log.Info("Owner retrieved successfully by email",
+		slog.Int64("id", owner.Id()),
+		slog.String("login", owner.Login()),
+	)
+
+	return owner, nil
+}
+
+// UpdateOwner Sets the fields of the owner named by the update, an empty display name clears it.
+// A replaced password goes to the history of the owner, keeping keepHistory newest entries
+func (s *Storage) UpdateOwner(ctx context.Context, owner models.Owner, fields []string, keepHistory int) error {
+	setClauses := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields)+1)
+	argId := 1
+	withPassword := false
+
+	for _, field := range fields {
+		switch field {
+		case models.OwnerFieldEmail:
+			// A changed address has to be verified again
+			setClauses = append(setClauses, fmt.Sprintf(
+				"email_verified=(email_verified AND email=$%d), email=$%d", argId, argId,
+			))
+			args = append(args, owner.Email())
+			argId++
+		case models.OwnerFieldLogin:
+			setClauses = append(setClauses, fmt.Sprintf("login=$%d", argId))
+			args = append(args, owner.Login())
+			argId++
+		case models.OwnerFieldPassword:
+			setClauses = append(setClauses, fmt.Sprintf(
+				"password_hash=$%d, pepper_version=$%d, password_changed_at=now()", argId, argId+1,
+			))
+			args = append(args, owner.PassHash(), owner.PepperVersion())
+			argId += 2
+			withPassword = true
+		case models.OwnerFieldDisplayName:
+			setClauses = append(setClauses, fmt.Sprintf("display_name=NULLIF($%d, '')", argId))
+			args = append(args, owner.DisplayName())
+			argId++
+		default:
+			return fmt.Errorf("unknown owner field %s", field)
+		}
 	}
-	if len(owner.PassHash()) > 0 {
-		setClauses = append(setClauses, fmt.Sprintf("password_hash=$%d", argId))
-		args = append(args, owner.PassHash())
-		argId++
+	if len(setClauses) == 0 {
+		return fmt.Errorf("no fields to update for owner with id %d", owner.Id())
 	}
 
 	query := fmt.Sprintf(`
@@ -144,16 +259,111 @@ func (s *Storage) UpdateOwner(ctx context.Context, owner models.Owner) error {
     `, strings.Join(setClauses, ", "), argId)
 	args = append(args, owner.Id())
 
-	result, err := s.pool.Exec(ctx, query, args...)
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if withPassword {
+			if err := rememberPassword(ctx, tx, owner.Id(), keepHistory); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+				return fmt.Errorf("failed to update owner: %w", storage.ErrOwnerExists)
+			}
+			return fmt.Errorf("failed to update owner: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("%w with id %d", storage.ErrOwnerNotFound, owner.Id())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Owner updated successfully", "id", owner.Id())
+
+	return nil
+}
+
+// UpdateOwnerPassword Sets the password hash and the time of the change,
+// the replaced hash goes to the history of the owner keeping keepHistory newest entries
+func (s *Storage) UpdateOwnerPassword(
+	ctx context.Context, id int64, passHash []byte, pepperVersion int, keepHistory int,
+) error {
+	query := `
+		UPDATE owners
+		SET password_hash=$1, pepper_version=$2, password_changed_at=now()
+		WHERE id=$3
+	`
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := rememberPassword(ctx, tx, id, keepHistory); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(ctx, query, passHash, pepperVersion, id)
+		if err != nil {
+			return fmt.Errorf("failed to update owner password: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("%w with id %d", storage.ErrOwnerNotFound, id)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update owner: %w", err)
+		return err
+	}
+
+	s.log.Info("Owner password updated", slog.Int64("id", id))
+
+	return nil
+}
+
+// RehashOwnerPassword Replaces the hash of an unchanged password, a password
+// changed meanwhile is kept and the new hash is dropped
+func (s *Storage) RehashOwnerPassword(
+	ctx context.Context, id int64, oldHash []byte, newHash []byte, pepperVersion int,
+) error {
+	query := `
+		UPDATE owners
+		SET password_hash=$1, pepper_version=$2
+		WHERE id=$3 AND password_hash=$4
+	`
+
+	result, err := s.pool.Exec(ctx, query, newHash, pepperVersion, id, oldHash)
+	if err != nil {
+		return fmt.Errorf("failed to rehash owner password: %w", err)
+	}
+
+	s.log.Info("Owner password rehashed", slog.Int64("id", id), slog.Int64("rows", result.RowsAffected()))
+
+	return nil
+}
+
+// VerifyOwnerEmail Marks the email of the owner as verified while it is still the given one
+// and not verified yet, so a verification token works once
+func (s *Storage) VerifyOwnerEmail(ctx context.Context, id int64, email string) error {
+	query := `
+		UPDATE owners
+		SET email_verified=TRUE
+		WHERE id=$1 AND email=$2 AND NOT email_verified
+	`
+
+	result, err := s.pool.Exec(ctx, query, id, email)
+	if err != nil {
+		return fmt.Errorf("failed to verify owner email: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("%w with id %d", storage.ErrOwnerNotFound, owner.Id())
+		return fmt.Errorf("%w with id %d and email %s", storage.ErrOwnerNotFound, id, email)
 	}
 
-	s.log.Info("Owner updated successfully", "id", owner.Id())
+	s.log.Info("Owner email verified", slog.Int64("id", id))
 
 	return nil
 }
@@ -183,7 +393,8 @@ func (s *Storage) deleteOwnerById(ctx context.Context, id int64) error {
 }
 
 func (s *Storage) deleteOwnerByLogin(ctx context.Context, login string) error {
-	query := `DELETE FROM owners WHERE login=$1`
+	// Accounts of organizations are deleted by id, a login names an owner without an organization
+	query := `DELETE FROM owners WHERE login=$1 AND org_id IS NULL`
 	commandTag, err := s.pool.Exec(ctx, query, login)
 	if err != nil {
 		return fmt.Errorf("failed to delete owner by login: %w", err)
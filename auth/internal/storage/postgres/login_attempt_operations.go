@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+// GetLoginAttempts Returns the counters of the keys which have failures, unknown keys are skipped
+func (s *Storage) GetLoginAttempts(
+	ctx context.Context, keys []models.LoginAttemptKey,
+) ([]models.LoginAttempts, error) {
+	kinds := make([]string, 0, len(keys))
+	subjects := make([]string, 0, len(keys))
+	for _, key := range keys {
+		kinds = append(kinds, key.Kind)
+		subjects = append(subjects, key.Subject)
+	}
+
+	query := `
+		SELECT a.kind, a.subject, a.failures, a.last_failure_at, a.blocked_until, a.locked
+		FROM login_attempts a
+		JOIN unnest($1::text[], $2::text[]) AS k(kind, subject)
+			ON a.kind = k.kind AND a.subject = k.subject
+	`
+
+	rows, err := s.pool.Query(ctx, query, kinds, subjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	attempts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.LoginAttempts, error) {
+		var a models.LoginAttempts
+		errS := row.Scan(
+			&a.Key.Kind, &a.Key.Subject, &a.Failures, &a.LastFailureAt, &a.BlockedUntil, &a.Locked,
+		)
+		return a, errS
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ReserveLoginAttempt Counts the attempt as a failure before its password is checked and blocks
+// the counter past a limit, a successful login releases the attempt. A counter blocked at the time
+// is returned as is with reserved false. The counter row is locked until its block is set,
+// so concurrent attempts see the blocks of each other.
+// A counter without failures since windowStart starts over with its block lifted
+func (s *Storage) ReserveLoginAttempt(
+	ctx context.Context, key models.LoginAttemptKey, at time.Time, windowStart time.Time,
+	block models.LoginBlockPolicy,
+) (models.LoginAttempts, bool, error) {
+	var attempts models.LoginAttempts
+	var reserved bool
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		attempts, err = lockLoginAttempts(ctx, tx, key, at)
+		if err != nil {
+			return err
+		}
+
+		if attempts.BlockedUntil != nil && attempts.BlockedUntil.After(at) {
+			return nil
+		}
+
+		if attempts.LastFailureAt.Before(windowStart) {
+			attempts.Failures, attempts.BlockedUntil, attempts.Locked = 0, nil, false
+		}
+		attempts.Failures++
+		attempts.LastFailureAt = at
+		if delay, locked := block(attempts.Failures); delay > 0 {
+			until := at.Add(delay)
+			attempts.BlockedUntil = &until
+			attempts.Locked = attempts.Locked || locked
+		}
+
+		reserved = true
+		return saveLoginAttempts(ctx, tx, attempts)
+	})
+	if err != nil {
+		return models.LoginAttempts{}, false, fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+
+	if reserved && attempts.Locked {
+		s.log.Warn("Login locked", slog.String("kind", key.Kind), slog.String("subject", key.Subject))
+	}
+
+	return attempts, reserved, nil
+}
+
+// ReleaseLoginAttempt Uncounts an attempt of a successful login, the block of the counter
+// is set again for the failures left, a lockout is kept
+func (s *Storage) ReleaseLoginAttempt(
+	ctx context.Context, key models.LoginAttemptKey, block models.LoginBlockPolicy,
+) error {
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		attempts, err := lockLoginAttempts(ctx, tx, key, time.Now())
+		if err != nil {
+			return err
+		}
+		if attempts.Locked {
+			return nil
+		}
+
+		attempts.Failures = max(attempts.Failures-1, 0)
+		attempts.BlockedUntil = nil
+		if delay, _ := block(attempts.Failures); delay > 0 {
+			until := attempts.LastFailureAt.Add(delay)
+			attempts.BlockedUntil = &until
+		}
+
+		return saveLoginAttempts(ctx, tx, attempts)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// lockLoginAttempts Locks the counter within the transaction, a missing one is created without failures
+func lockLoginAttempts(
+	ctx context.Context, tx pgx.Tx, key models.LoginAttemptKey, at time.Time,
+) (models.LoginAttempts, error) {
+	queryInsert := `
+		INSERT INTO login_attempts (kind, subject, failures, last_failure_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (kind, subject) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, queryInsert, key.Kind, key.Subject, at); err != nil {
+		return models.LoginAttempts{}, err
+	}
+
+	querySelect := `
+		SELECT failures, last_failure_at, blocked_until, locked
+		FROM login_attempts
+		WHERE kind=$1 AND subject=$2
+		FOR UPDATE
+	`
+
+	attempts := models.LoginAttempts{Key: key}
+	err := tx.QueryRow(ctx, querySelect, key.Kind, key.Subject).Scan(
+		&attempts.Failures, &attempts.LastFailureAt, &attempts.BlockedUntil, &attempts.Locked,
+	)
+	if err != nil {
+		return models.LoginAttempts{}, err
+	}
+
+	return attempts, nil
+}
+
+func saveLoginAttempts(ctx context.Context, tx pgx.Tx, attempts models.LoginAttempts) error {
+	query := `
+		UPDATE login_attempts
+		SET failures=$3, last_failure_at=$4, blocked_until=$5, locked=$6
+		WHERE kind=$1 AND subject=$2
+	`
+
+	_, err := tx.Exec(ctx, query,
+		attempts.Key.Kind, attempts.Key.Subject,
+		attempts.Failures, attempts.LastFailureAt, attempts.BlockedUntil, attempts.Locked,
+	)
+	return err
+}
+
+// DeleteExpiredLoginAttempts Drops the counters of the kind without failures since failedBefore
+// and without a block at the time, they would start over on the next attempt anyway
+func (s *Storage) DeleteExpiredLoginAttempts(
+	ctx context.Context, kind string, failedBefore time.Time, at time.Time,
+) (int64, error) {
+	query := `
+		DELETE FROM login_attempts
+		WHERE kind=$1 AND last_failure_at < $2 AND (blocked_until IS NULL OR blocked_until <= $3)
+	`
+
+	result, err := s.pool.Exec(ctx, query, kind, failedBefore, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired login attempts: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ResetLoginAttempts Drops the counter, reports whether it existed
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key models.LoginAttemptKey) (bool, error) {
+	query := `DELETE FROM login_attempts WHERE kind=$1 AND subject=$2`
+
+	result, err := s.pool.Exec(ctx, query, key.Kind, key.Subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// SaveTOTP Saves a pending secret of the owner with its recovery codes, replacing
+// a pending one. A confirmed secret is kept until the factor is deleted
+func (s *Storage) SaveTOTP(ctx context.Context, ownerId int64, secret []byte, codeHashes [][]byte) error {
+	const op = "postgres.saveTOTP"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queryUpsert := `
+			INSERT INTO owner_totp (owner_id, secret)
+			VALUES ($1, $2)
+			ON CONFLICT (owner_id) DO UPDATE
+			SET secret=EXCLUDED.secret, created_at=now(), last_used_step=0
+			WHERE owner_totp.confirmed_at IS NULL
+		`
+		result, err := tx.Exec(ctx, queryUpsert, ownerId, secret)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return storage.ErrTOTPConfirmed
+		}
+
+		return replaceRecoveryCodes(ctx, tx, ownerId, codeHashes)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to save totp: %w", op, err)
+	}
+
+	s.log.Info("TOTP saved successfully", slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+func (s *Storage) GetTOTP(ctx context.Context, ownerId int64) (models.TOTP, error) {
+	query := `
+		SELECT owner_id, secret, confirmed_at, last_used_step
+		FROM owner_totp
+		WHERE owner_id=$1
+	`
+
+	var totp models.TOTP
+	err := s.pool.QueryRow(ctx, query, ownerId).Scan(
+		&totp.OwnerId, &totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, fmt.Errorf("%w for owner %d", storage.ErrTOTPNotFound, ownerId)
+		}
+		return models.TOTP{}, fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	return totp, nil
+}
+
+// UseTOTPStep Records the step of an accepted code, a code is accepted only once.
+// Confirms a pending secret
+func (s *Storage) UseTOTPStep(ctx context.Context, ownerId int64, step int64) error {
+	query := `
+		UPDATE owner_totp
+		SET last_used_step=$2, confirmed_at=COALESCE(confirmed_at, now())
+		WHERE owner_id=$1 AND last_used_step<$2
+	`
+
+	result, err := s.pool.Exec(ctx, query, ownerId, step)
+	if err != nil {
+		return fmt.Errorf("failed to use totp step: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w for owner %d", storage.ErrTOTPStepUsed, ownerId)
+	}
+
+	return nil
+}
+
+// DeleteTOTP Deletes the factor of the owner with its recovery codes
+func (s *Storage) DeleteTOTP(ctx context.Context, ownerId int64) error {
+	const op = "postgres.deleteTOTP"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `DELETE FROM owner_totp WHERE owner_id=$1`, ownerId)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return storage.ErrTOTPNotFound
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM recovery_codes WHERE owner_id=$1`, ownerId)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete totp: %w", op, err)
+	}
+
+	s.log.Info("TOTP deleted", slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+// ReplaceRecoveryCodes Replaces every recovery code of the owner
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, ownerId int64, codeHashes [][]byte) error {
+	const op = "postgres.replaceRecoveryCodes"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, ownerId, codeHashes)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to replace recovery codes: %w", op, err)
+	}
+
+	s.log.Info("Recovery codes replaced", slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+// GetRecoveryCodes Returns the unused recovery codes of the owner
+func (s *Storage) GetRecoveryCodes(ctx context.Context, ownerId int64) ([]models.RecoveryCode, error) {
+	query := `
+		SELECT id, owner_id, code_hash
+		FROM recovery_codes
+		WHERE owner_id=$1 AND used_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := s.pool.Query(ctx, query, ownerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := make([]models.RecoveryCode, 0)
+	for rows.Next() {
+		var code models.RecoveryCode
+		if err = rows.Scan(&code.Id, &code.OwnerId, &code.CodeHash); err != nil {
+			return nil, fmt.Errorf("failed to scan recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode Marks the code as used, exactly one concurrent caller succeeds
+func (s *Storage) UseRecoveryCode(ctx context.Context, id int64) error {
+	query := `
+		UPDATE recovery_codes
+		SET used_at=now()
+		WHERE id=$1 AND used_at IS NULL
+	`
+
+	result, err := s.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id %d", storage.ErrRecoveryCodeUsed, id)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveMFAChallenge(ctx context.Context, challenge models.MFAChallenge) error {
+	const op = "postgres.saveMFAChallenge"
+
+	queryInsert := `
+		INSERT INTO mfa_challenges (token_hash, owner_id, app_id, org_id, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5)
+    `
+
+	_, err := s.pool.Exec(ctx, queryInsert,
+		challenge.TokenHash, challenge.OwnerId, challenge.AppId, challenge.OrgId, challenge.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save mfa challenge: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetMFAChallenge(ctx context.Context, tokenHash []byte) (models.MFAChallenge, error) {
+	query := `
+		SELECT id, token_hash, owner_id, app_id, COALESCE(org_id, 0), attempts, expires_at, used_at
+		FROM mfa_challenges
+		WHERE token_hash=$1
+	`
+
+	var challenge models.MFAChallenge
+	err := s.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&challenge.Id, &challenge.TokenHash, &challenge.OwnerId, &challenge.AppId, &challenge.OrgId,
+		&challenge.Attempts, &challenge.ExpiresAt, &challenge.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.MFAChallenge{}, storage.ErrMFAChallengeNotFound
+		}
+		return models.MFAChallenge{}, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// ReserveMFAChallengeAttempt Counts an attempt of an unused challenge before its code is checked
+// and returns the attempts made, a challenge with maxAttempts made is used up
+func (s *Storage) ReserveMFAChallengeAttempt(ctx context.Context, id int64, maxAttempts int) (int, error) {
+	query := `
+		UPDATE mfa_challenges
+		SET attempts=attempts+1
+		WHERE id=$1 AND used_at IS NULL AND attempts < $2
+		RETURNING attempts
+	`
+
+	var attempts int
+	if err := s.pool.QueryRow(ctx, query, id, maxAttempts).Scan(&attempts); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, storage.ErrMFAChallengeUsed
+		}
+		return 0, fmt.Errorf("failed to reserve mfa challenge attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// UseMFAChallenge Marks the challenge as used, exactly one concurrent caller succeeds
+func (s *Storage) UseMFAChallenge(ctx context.Context, id int64) error {
+	query := `
+		UPDATE mfa_challenges
+		SET used_at=now()
+		WHERE id=$1 AND used_at IS NULL
+	`
+
+	result, err := s.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id %d", storage.ErrMFAChallengeUsed, id)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, ownerId int64, codeHashes [][]byte) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE owner_id=$1`, ownerId); err != nil {
+		return err
+	}
+
+	queryInsert := `
+		INSERT INTO recovery_codes (owner_id, code_hash)
+		SELECT $1, unnest($2::bytea[])
+	`
+	_, err := tx.Exec(ctx, queryInsert, ownerId, codeHashes)
+	return err
+}
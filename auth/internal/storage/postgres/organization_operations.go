@@ -0,0 +1,342 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const (
+	constraintMemberExternalId = "idx_organization_members_external_id"
+	constraintMemberOrg        = "organization_members_org_id_fkey"
+)
+
+const queryMembers = `
+	SELECT m.org_id, m.owner_id, o.login, o.email, m.role, COALESCE(m.external_id, ''), m.joined_at,
+		m.accepted_at IS NULL
+	FROM organization_members m
+	JOIN owners o ON o.id = m.owner_id
+`
+
+// CreateOrganization Saves the organization with its creator as the owner member, returns its id
+func (s *Storage) CreateOrganization(ctx context.Context, org models.Organization, creatorId int64) (int64, error) {
+	const op = "postgres.createOrganization"
+
+	var id int64
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queryOrg := `
+			INSERT INTO organizations (name, slug, scoped_accounts)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`
+		if err := tx.QueryRow(ctx, queryOrg, org.Name, org.Slug, org.ScopedAccounts).Scan(&id); err != nil {
+			return err
+		}
+
+		queryMember := `
+			INSERT INTO organization_members (org_id, owner_id, role, accepted_at)
+			VALUES ($1, $2, $3, now())
+		`
+		_, err := tx.Exec(ctx, queryMember, id, creatorId, models.OrgRoleOwner)
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return 0, fmt.Errorf("%s: %w with slug %s", op, storage.ErrOrganizationExists, org.Slug)
+			case pgerrcode.ForeignKeyViolation:
+				return 0, fmt.Errorf("%s: %w with id %d", op, storage.ErrOwnerNotFound, creatorId)
+			}
+		}
+		return 0, fmt.Errorf("%s: failed to create organization: %w", op, err)
+	}
+
+	s.log.Info("Organization created",
+		slog.Int64("org_id", id),
+		slog.String("slug", org.Slug),
+		slog.Int64("owner_id", creatorId),
+	)
+
+	return id, nil
+}
+
+func (s *Storage) GetOrganization(ctx context.Context, id int64) (models.Organization, error) {
+	query := `
+		SELECT id, name, slug, created_at, scoped_accounts
+		FROM organizations
+		WHERE id=$1
+	`
+
+	var org models.Organization
+	err := s.pool.QueryRow(ctx, query, id).Scan(&org.Id, &org.Name, &org.Slug, &org.CreatedAt, &org.ScopedAccounts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Organization{}, fmt.Errorf("%w with id %d", storage.ErrOrganizationNotFound, id)
+		}
+		return models.Organization{}, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return org, nil
+}
+
+// ListOwnerOrganizations Returns the organizations the owner is a member of or is invited to with its role in them
+func (s *Storage) ListOwnerOrganizations(ctx context.Context, ownerId int64) ([]models.Organization, error) {
+	query := `
+		SELECT o.id, o.name, o.slug, o.created_at, o.scoped_accounts, m.role, m.accepted_at IS NULL
+		FROM organizations o
+		JOIN organization_members m ON m.org_id = o.id
+		WHERE m.owner_id=$1
+		ORDER BY o.name, o.id
+	`
+
+	rows, err := s.pool.Query(ctx, query, ownerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	orgs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Organization, error) {
+		var org models.Organization
+		errS := row.Scan(&org.Id, &org.Name, &org.Slug, &org.CreatedAt, &org.ScopedAccounts, &org.Role, &org.Pending)
+		return org, errS
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+func (s *Storage) GetMembership(ctx context.Context, orgId int64, ownerId int64) (models.Membership, error) {
+	query := queryMembers + `
+		WHERE m.org_id=$1 AND m.owner_id=$2
+	`
+
+	rows, err := s.pool.Query(ctx, query, orgId, ownerId)
+	if err != nil {
+		return models.Membership{}, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	member, err := pgx.CollectExactlyOneRow(rows, scanMember)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Membership{}, fmt.Errorf("%w: owner %d in organization %d",
+				storage.ErrMemberNotFound, ownerId, orgId)
+		}
+		return models.Membership{}, fmt.Errorf("failed to scan membership: %w", err)
+	}
+
+	return member, nil
+}
+
+// ListMembers Returns the members of the organization who accepted the invitation in the order they joined
+func (s *Storage) ListMembers(ctx context.Context, orgId int64) ([]models.Membership, error) {
+	query := queryMembers + `
+		WHERE m.org_id=$1 AND m.accepted_at IS NOT NULL
+		ORDER BY m.joined_at, m.owner_id
+	`
+
+	rows, err := s.pool.Query(ctx, query, orgId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	members, err := pgx.CollectRows(rows, scanMember)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan members: %w", err)
+	}
+
+	return members, nil
+}
+
+// AddMember Invites the owner to the organization, an empty external id is stored as none.
+// A pending invitation is renewed with the new role and external id, an accepted member is not changed
+func (s *Storage) AddMember(ctx context.Context, member models.Membership) error {
+	const op = "postgres.addMember"
+
+	queryInsert := `
+		INSERT INTO organization_members (org_id, owner_id, role, external_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (org_id, owner_id) DO UPDATE
+		SET role = EXCLUDED.role, external_id = EXCLUDED.external_id, joined_at = now()
+		WHERE organization_members.accepted_at IS NULL
+	`
+
+	tag, err := s.pool.Exec(ctx, queryInsert, member.OrgId, member.OwnerId, member.Role, member.ExternalId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == constraintMemberExternalId:
+				return fmt.Errorf("%s: %w: %s", op, storage.ErrExternalIdExists, member.ExternalId)
+			case pgErr.Code == pgerrcode.ForeignKeyViolation && pgErr.ConstraintName == constraintMemberOrg:
+				return fmt.Errorf("%s: %w with id %d", op, storage.ErrOrganizationNotFound, member.OrgId)
+			case pgErr.Code == pgerrcode.ForeignKeyViolation:
+				return fmt.Errorf("%s: %w with id %d", op, storage.ErrOwnerNotFound, member.OwnerId)
+			}
+		}
+		return fmt.Errorf("%s: failed to add member: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w: owner %d", op, storage.ErrMemberExists, member.OwnerId)
+	}
+
+	s.log.Info("Member invited",
+		slog.Int64("org_id", member.OrgId),
+		slog.Int64("owner_id", member.OwnerId),
+		slog.String("role", member.Role),
+	)
+
+	return nil
+}
+
+// SaveOrgOwner Saves an account of the organization together with its accepted membership, returns its id
+func (s *Storage) SaveOrgOwner(ctx context.Context, owner models.Owner, member models.Membership) (int64, error) {
+	const op = "postgres.saveOrgOwner"
+
+	var id int64
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queryOwner := `
+			INSERT INTO owners (email, login, password_hash, pepper_version, org_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`
+		err := tx.QueryRow(ctx, queryOwner,
+			owner.Email(), owner.Login(), owner.PassHash(), owner.PepperVersion(), owner.OrgId(),
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		queryMember := `
+			INSERT INTO organization_members (org_id, owner_id, role, external_id, accepted_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), now())
+		`
+		_, err = tx.Exec(ctx, queryMember, owner.OrgId(), id, member.Role, member.ExternalId)
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == constraintMemberExternalId:
+				return 0, fmt.Errorf("%s: %w: %s", op, storage.ErrExternalIdExists, member.ExternalId)
+			case pgErr.Code == pgerrcode.UniqueViolation:
+				return 0, fmt.Errorf("%s: failed to save owner: %w", op, storage.ErrOwnerExists)
+			case pgErr.Code == pgerrcode.ForeignKeyViolation:
+				return 0, fmt.Errorf("%s: %w with id %d", op, storage.ErrOrganizationNotFound, owner.OrgId())
+			}
+		}
+		return 0, fmt.Errorf("%s: failed to save owner: %w", op, err)
+	}
+
+	s.log.Info("Organization account created",
+		slog.Int64("id", id),
+		slog.Int64("org_id", owner.OrgId()),
+		slog.String("login", owner.Login()),
+	)
+
+	return id, nil
+}
+
+// ExternalIdUsed Reports whether a member or an invitation of the organization has the external id
+func (s *Storage) ExternalIdUsed(ctx context.Context, orgId int64, externalId string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM organization_members WHERE org_id=$1 AND external_id=$2)
+	`
+
+	var used bool
+	if err := s.pool.QueryRow(ctx, query, orgId, externalId).Scan(&used); err != nil {
+		return false, fmt.Errorf("failed to check external id: %w", err)
+	}
+
+	return used, nil
+}
+
+// AcceptMember Makes the invitation of the owner a membership, the owner joins the organization now
+func (s *Storage) AcceptMember(ctx context.Context, orgId int64, ownerId int64) error {
+	const op = "postgres.acceptMember"
+
+	query := `
+		UPDATE organization_members
+		SET accepted_at = now(), joined_at = now()
+		WHERE org_id=$1 AND owner_id=$2 AND accepted_at IS NULL
+	`
+
+	tag, err := s.pool.Exec(ctx, query, orgId, ownerId)
+	if err != nil {
+		return fmt.Errorf("%s: failed to accept invitation: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w: no invitation of owner %d to organization %d",
+			op, storage.ErrMemberNotFound, ownerId, orgId)
+	}
+
+	s.log.Info("Invitation accepted", slog.Int64("org_id", orgId), slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+// RemoveMember Removes the owner from the organization unless it is the last owner member of it
+func (s *Storage) RemoveMember(ctx context.Context, orgId int64, ownerId int64) error {
+	const op = "postgres.removeMember"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// Locks the organization so that concurrent removals can not leave it without an owner
+		queryLock := `SELECT id FROM organizations WHERE id=$1 FOR UPDATE`
+		if _, err := tx.Exec(ctx, queryLock, orgId); err != nil {
+			return err
+		}
+
+		var role string
+		var pending bool
+		queryRole := `SELECT role, accepted_at IS NULL FROM organization_members WHERE org_id=$1 AND owner_id=$2`
+		err := tx.QueryRow(ctx, queryRole, orgId, ownerId).Scan(&role, &pending)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: owner %d in organization %d", storage.ErrMemberNotFound, ownerId, orgId)
+			}
+			return err
+		}
+
+		// Invited owners do not keep the organization until they accept
+		if role == models.OrgRoleOwner && !pending {
+			var owners int
+			queryOwners := `
+				SELECT count(*) FROM organization_members
+				WHERE org_id=$1 AND role=$2 AND accepted_at IS NOT NULL
+			`
+			if err = tx.QueryRow(ctx, queryOwners, orgId, models.OrgRoleOwner).Scan(&owners); err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return fmt.Errorf("%w with id %d", storage.ErrLastOrgOwner, orgId)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM organization_members WHERE org_id=$1 AND owner_id=$2`, orgId, ownerId)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to remove member: %w", op, err)
+	}
+
+	s.log.Info("Member removed", slog.Int64("org_id", orgId), slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+func scanMember(row pgx.CollectableRow) (models.Membership, error) {
+	var m models.Membership
+	err := row.Scan(&m.OrgId, &m.OwnerId, &m.Login, &m.Email, &m.Role, &m.ExternalId, &m.JoinedAt, &m.Pending)
+	return m, err
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+// ownerSortColumns The sort fields mapped to columns, they are never taken from the request as is
+var ownerSortColumns = map[string]string{
+	models.OwnerSortId:        "o.id",
+	models.OwnerSortCreatedAt: "o.created_at",
+	models.OwnerSortLogin:     "o.login",
+	models.OwnerSortEmail:     "o.email",
+}
+
+// queryOwnerLocked Matches the login counters locked out right now
+const queryOwnerLocked = `EXISTS (
+	SELECT 1 FROM login_attempts a
+	WHERE a.kind = 'login' AND a.subject = o.login AND a.locked AND a.blocked_until > now()
+)`
+
+// ListOwners Returns up to the limit of owners after the cursor of the query in its order
+func (s *Storage) ListOwners(ctx context.Context, q models.OwnerListQuery) ([]models.Owner, error) {
+	column, ok := ownerSortColumns[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown owner sort field %s", q.Sort)
+	}
+
+	b := ownerQueryBuilder{}
+	b.filter(q.Filter)
+	if q.After != nil {
+		b.after(column, q.Desc, *q.After)
+	}
+
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	query := `
+		SELECT o.id, o.email, o.login, o.email_verified, o.created_at, COALESCE(o.display_name, ''),
+			` + queryOwnerLocked + `
+		FROM owners o
+	` + b.where() + fmt.Sprintf(`
+		ORDER BY %s %s, o.id %s
+		LIMIT %s
+	`, column, direction, direction, b.arg(q.Limit))
+
+	rows, err := s.pool.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list owners: %w", err)
+	}
+
+	owners, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Owner, error) {
+		return scanListedOwner(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+// SearchOwners Returns the owners whose login or email is similar to the query or contains it,
+// the best matches first. The score is the best trigram similarity of the login and the email
+func (s *Storage) SearchOwners(ctx context.Context, q models.OwnerSearch) ([]models.OwnerMatch, error) {
+	b := ownerQueryBuilder{}
+	text := b.arg(q.Text)
+	b.conditions = append(b.conditions, fmt.Sprintf(`(
+		o.login %% %[1]s OR o.email %% %[1]s OR %[1]s <%% o.login OR %[1]s <%% o.email
+		OR o.login ILIKE %[2]s OR o.email ILIKE %[2]s
+	)`, text, b.arg("%"+escapeLike(q.Text)+"%")))
+	if q.OwnerId != 0 {
+		b.conditions = append(b.conditions, "o.id = "+b.arg(q.OwnerId))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT o.id, o.email, o.login, o.email_verified, o.created_at, COALESCE(o.display_name, ''), %[1]s,
+			GREATEST(
+				similarity(o.login, %[2]s), similarity(o.email, %[2]s),
+				word_similarity(%[2]s, o.login), word_similarity(%[2]s, o.email)
+			) AS score
+		FROM owners o
+		%[3]s
+		ORDER BY score DESC, o.id
+		LIMIT %[4]s
+	`, queryOwnerLocked, text, b.where(), b.arg(q.Limit))
+
+	rows, err := s.pool.Query(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search owners: %w", err)
+	}
+
+	matches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OwnerMatch, error) {
+		var score float32
+		owner, errS := scanListedOwner(row, &score)
+		return models.OwnerMatch{Owner: owner, Score: score}, errS
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan owner matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+// scanListedOwner Scans the owner columns of listings, extra columns follow them
+func scanListedOwner(row pgx.CollectableRow, extra ...any) (models.Owner, error) {
+	var id int64
+	var email, login, displayName string
+	var emailVerified, locked bool
+	var createdAt time.Time
+
+	dest := append([]any{&id, &email, &login, &emailVerified, &createdAt, &displayName, &locked}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.Owner{}, err
+	}
+
+	var owner models.Owner
+	_ = owner.SetId(id)
+	_ = owner.SetEmail(email)
+	_ = owner.SetLogin(login)
+	owner.SetEmailVerified(emailVerified)
+	owner.SetCreatedAt(createdAt)
+	owner.SetLocked(locked)
+	_ = owner.SetDisplayName(displayName)
+
+	return owner, nil
+}
+
+// ownerQueryBuilder Collects the conditions of an owner listing with their positional arguments
+type ownerQueryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (b *ownerQueryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *ownerQueryBuilder) filter(f models.OwnerFilter) {
+	if f.LoginPrefix != "" {
+		b.conditions = append(b.conditions, "o.login LIKE "+b.arg(likePrefix(f.LoginPrefix)))
+	}
+	if f.EmailPrefix != "" {
+		b.conditions = append(b.conditions, "o.email LIKE "+b.arg(likePrefix(f.EmailPrefix)))
+	}
+	if !f.CreatedAfter.IsZero() {
+		b.conditions = append(b.conditions, "o.created_at >= "+b.arg(f.CreatedAfter))
+	}
+	if !f.CreatedBefore.IsZero() {
+		b.conditions = append(b.conditions, "o.created_at < "+b.arg(f.CreatedBefore))
+	}
+	if f.Verified != nil {
+		b.conditions = append(b.conditions, "o.email_verified = "+b.arg(*f.Verified))
+	}
+	if f.Locked != nil {
+		b.conditions = append(b.conditions, queryOwnerLocked+" = "+b.arg(*f.Locked))
+	}
+}
+
+// after Keeps the owners past the cursor, a row comparison matches the order by the column and the id
+func (b *ownerQueryBuilder) after(column string, desc bool, cursor models.OwnerCursor) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+
+	var value any
+	switch column {
+	case ownerSortColumns[models.OwnerSortId]:
+		b.conditions = append(b.conditions, fmt.Sprintf("o.id %s %s", op, b.arg(cursor.Id)))
+		return
+	case ownerSortColumns[models.OwnerSortCreatedAt]:
+		value = cursor.CreatedAt
+	case ownerSortColumns[models.OwnerSortLogin]:
+		value = cursor.Login
+	case ownerSortColumns[models.OwnerSortEmail]:
+		value = cursor.Email
+	}
+
+	b.conditions = append(b.conditions,
+		fmt.Sprintf("(%s, o.id) %s (%s, %s)", column, op, b.arg(value), b.arg(cursor.Id)),
+	)
+}
+
+func (b *ownerQueryBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func likePrefix(prefix string) string {
+	return escapeLike(prefix) + "%"
+}
+
+// escapeLike Escapes the LIKE wildcards of the text, '\' is the default escape character
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+)
+
+// GetPasswordHistory Returns up to limit previous password hashes of the owner, the newest first
+func (s *Storage) GetPasswordHistory(
+	ctx context.Context, ownerId int64, limit int,
+) ([]models.PasswordHistoryEntry, error) {
+	query := `
+		SELECT id, owner_id, password_hash, pepper_version, created_at
+		FROM password_history
+		WHERE owner_id=$1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.pool.Query(ctx, query, ownerId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PasswordHistoryEntry, error) {
+		var e models.PasswordHistoryEntry
+		errS := row.Scan(&e.Id, &e.OwnerId, &e.PassHash, &e.PepperVersion, &e.CreatedAt)
+		return e, errS
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan password history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// rememberPassword Copies the current password hash of the owner into the history
+// and drops the entries beyond keep newest, keep of zero keeps no history
+func rememberPassword(ctx context.Context, tx pgx.Tx, ownerId int64, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	queryInsert := `
+		INSERT INTO password_history (owner_id, password_hash, pepper_version)
+		SELECT id, password_hash, pepper_version
+		FROM owners
+		WHERE id=$1
+	`
+	if _, err := tx.Exec(ctx, queryInsert, ownerId); err != nil {
+		return fmt.Errorf("failed to add password history: %w", err)
+	}
+
+	queryTrim := `
+		DELETE FROM password_history
+		WHERE owner_id=$1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE owner_id=$1
+			ORDER BY id DESC
+			LIMIT $2
+		)
+	`
+	if _, err := tx.Exec(ctx, queryTrim, ownerId, keep); err != nil {
+		return fmt.Errorf("failed to trim password history: %w", err)
+	}
+
+	return nil
+}
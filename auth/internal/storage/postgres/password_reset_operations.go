@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+// SavePasswordReset Saves the reset unless the owner has limit resets created since then,
+// reports whether it is saved. The owner row is locked, so concurrent requests can't pass the limit.
+// A zero limit saves every reset
+func (s *Storage) SavePasswordReset(
+	ctx context.Context, reset models.PasswordReset, since time.Time, limit int,
+) (bool, error) {
+	const op = "postgres.savePasswordReset"
+
+	saved := false
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if limit > 0 {
+			queryLock := `SELECT id FROM owners WHERE id=$1 FOR UPDATE`
+			if err := tx.QueryRow(ctx, queryLock, reset.OwnerId).Scan(new(int64)); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("%w with id %d", storage.ErrOwnerNotFound, reset.OwnerId)
+				}
+				return err
+			}
+
+			queryCount := `
+				SELECT count(*)
+				FROM password_resets
+				WHERE owner_id=$1 AND created_at > $2
+			`
+			var count int
+			if err := tx.QueryRow(ctx, queryCount, reset.OwnerId, since).Scan(&count); err != nil {
+				return err
+			}
+			if count >= limit {
+				return nil
+			}
+		}
+
+		queryInsert := `
+			INSERT INTO password_resets (token_hash, owner_id, expires_at)
+			VALUES ($1, $2, $3)
+		`
+		if _, err := tx.Exec(ctx, queryInsert, reset.TokenHash, reset.OwnerId, reset.ExpiresAt); err != nil {
+			return err
+		}
+
+		saved = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to save password reset: %w", op, err)
+	}
+
+	if saved {
+		s.log.Info("Password reset saved successfully", slog.Int64("owner_id", reset.OwnerId))
+	}
+
+	return saved, nil
+}
+
+func (s *Storage) GetPasswordReset(ctx context.Context, tokenHash []byte) (models.PasswordReset, error) {
+	query := `
+		SELECT id, token_hash, owner_id, expires_at, used_at
+		FROM password_resets
+		WHERE token_hash=$1
+	`
+
+	var reset models.PasswordReset
+	err := s.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&reset.Id, &reset.TokenHash, &reset.OwnerId, &reset.ExpiresAt, &reset.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PasswordReset{}, storage.ErrPasswordResetNotFound
+		}
+		return models.PasswordReset{}, fmt.Errorf("failed to get password reset: %w", err)
+	}
+
+	return reset, nil
+}
+
+// ResetOwnerPassword Uses the reset and sets the password hash in one transaction,
+// exactly one concurrent caller succeeds. Other pending resets of the owner are used up,
+// the replaced hash goes to the history of the owner keeping keepHistory newest entries
+func (s *Storage) ResetOwnerPassword(
+	ctx context.Context, reset models.PasswordReset, passHash []byte, pepperVersion int, keepHistory int,
+) error {
+	const op = "postgres.resetOwnerPassword"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queryUse := `
+			UPDATE password_resets
+			SET used_at=now()
+			WHERE owner_id=$1 AND used_at IS NULL
+			RETURNING id
+		`
+		rows, err := tx.Query(ctx, queryUse, reset.OwnerId)
+		if err != nil {
+			return err
+		}
+		used, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, id := range used {
+			found = found || id == reset.Id
+		}
+		if !found {
+			return fmt.Errorf("%w with id %d", storage.ErrPasswordResetUsed, reset.Id)
+		}
+
+		if err = rememberPassword(ctx, tx, reset.OwnerId, keepHistory); err != nil {
+			return err
+		}
+
+		queryUpdate := `
+			UPDATE owners
+			SET password_hash=$1, pepper_version=$2, password_changed_at=now()
+			WHERE id=$3
+		`
+		result, err := tx.Exec(ctx, queryUpdate, passHash, pepperVersion, reset.OwnerId)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("%w with id %d", storage.ErrOwnerNotFound, reset.OwnerId)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to reset password: %w", op, err)
+	}
+
+	s.log.Info("Owner password reset", slog.Int64("owner_id", reset.OwnerId))
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "postgres.saveRefreshToken"
+
+	queryInsert := `
+		INSERT INTO refresh_tokens (token_hash, family_id, owner_id, app_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+    `
+
+	_, err := s.pool.Exec(ctx, queryInsert,
+		token.TokenHash, token.FamilyId, token.OwnerId, token.AppId, token.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save refresh token: %w", op, err)
+	}
+
+	s.log.Info("Refresh token saved successfully",
+		slog.Int64("owner_id", token.OwnerId),
+		slog.String("family_id", token.FamilyId),
+	)
+
+	return nil
+}
+
+func (s *Storage) GetRefreshToken(ctx context.Context, tokenHash []byte) (models.RefreshToken, error) {
+	query := `
+		SELECT id, token_hash, family_id, owner_id, app_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash=$1
+	`
+
+	var token models.RefreshToken
+	err := s.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.Id, &token.TokenHash, &token.FamilyId, &token.OwnerId, &token.AppId,
+		&token.ExpiresAt, &token.UsedAt, &token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RefreshToken{}, storage.ErrRefreshTokenNotFound
+		}
+		return models.RefreshToken{}, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// UseRefreshToken Marks the token as used, exactly one concurrent caller succeeds
+func (s *Storage) UseRefreshToken(ctx context.Context, id int64) error {
+	query := `
+		UPDATE refresh_tokens
+		SET used_at=now()
+		WHERE id=$1 AND used_at IS NULL AND revoked_at IS NULL
+	`
+
+	result, err := s.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id %d", storage.ErrRefreshTokenUsed, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+func (s *Storage) SaveRevokedToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "postgres.saveRevokedToken"
+
+	queryInsert := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+    `
+
+	if _, err := s.pool.Exec(ctx, queryInsert, jti, expiresAt); err != nil {
+		return fmt.Errorf("%s: failed to save revoked token: %w", op, err)
+	}
+
+	s.log.Info("Token revoked successfully", slog.String("jti", jti))
+
+	return nil
+}
+
+func (s *Storage) GetRevokedToken(ctx context.Context, jti string) (time.Time, error) {
+	query := `
+		SELECT expires_at
+		FROM revoked_tokens
+		WHERE jti=$1 AND expires_at > now()
+	`
+
+	var expiresAt time.Time
+	err := s.pool.QueryRow(ctx, query, jti).Scan(&expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("%w with jti %s", storage.ErrRevokedTokenNotFound, jti)
+		}
+		return time.Time{}, fmt.Errorf("failed to get revoked token: %w", err)
+	}
+
+	return expiresAt, nil
+}
+
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= $1`
+
+	commandTag, err := s.pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+const queryRolesWithPermissions = `
+	SELECT r.id, r.name, r.description,
+		COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	LEFT JOIN permissions p ON p.id = rp.permission_id
+`
+
+// ListRoles Returns every role with its permissions ordered by name
+func (s *Storage) ListRoles(ctx context.Context) ([]models.Role, error) {
+	query := queryRolesWithPermissions + `
+		GROUP BY r.id
+		ORDER BY r.name
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return collectRoles(rows)
+}
+
+// GetOwnerRoles Returns the roles assigned to the owner with their permissions ordered by name
+func (s *Storage) GetOwnerRoles(ctx context.Context, ownerId int64) ([]models.Role, error) {
+	query := queryRolesWithPermissions + `
+		WHERE r.id IN (SELECT role_id FROM owner_roles WHERE owner_id=$1)
+		GROUP BY r.id
+		ORDER BY r.name
+	`
+
+	rows, err := s.pool.Query(ctx, query, ownerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owner roles: %w", err)
+	}
+
+	return collectRoles(rows)
+}
+
+// AssignRole Assigns the role to the owner, reports false when it is already assigned
+func (s *Storage) AssignRole(ctx context.Context, ownerId int64, role string) (bool, error) {
+	const op = "postgres.assignRole"
+
+	var roleId int32
+	err := s.pool.QueryRow(ctx, `SELECT id FROM roles WHERE name=$1`, role).Scan(&roleId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("%s: %w with name %s", op, storage.ErrRoleNotFound, role)
+		}
+		return false, fmt.Errorf("%s: failed to get role: %w", op, err)
+	}
+
+	queryInsert := `
+		INSERT INTO owner_roles (owner_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := s.pool.Exec(ctx, queryInsert, ownerId, roleId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return false, fmt.Errorf("%s: %w with id %d", op, storage.ErrOwnerNotFound, ownerId)
+		}
+		return false, fmt.Errorf("%s: failed to assign role: %w", op, err)
+	}
+
+	s.log.Info("Role assigned",
+		slog.Int64("owner_id", ownerId),
+		slog.String("role", role),
+		slog.Bool("assigned", result.RowsAffected() > 0),
+	)
+
+	return result.RowsAffected() > 0, nil
+}
+
+// RevokeRole Removes the role from the owner
+func (s *Storage) RevokeRole(ctx context.Context, ownerId int64, role string) error {
+	query := `
+		DELETE FROM owner_roles o
+		USING roles r
+		WHERE o.role_id = r.id AND o.owner_id=$1 AND r.name=$2
+	`
+
+	result, err := s.pool.Exec(ctx, query, ownerId, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s of owner %d", storage.ErrRoleNotAssigned, role, ownerId)
+	}
+
+	s.log.Info("Role revoked", slog.Int64("owner_id", ownerId), slog.String("role", role))
+
+	return nil
+}
+
+func collectRoles(rows pgx.Rows) ([]models.Role, error) {
+	roles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Role, error) {
+		var r models.Role
+		errS := row.Scan(&r.Id, &r.Name, &r.Description, &r.Permissions)
+		return r, errS
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan roles: %w", err)
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage"
+)
+
+func (s *Storage) SaveSession(ctx context.Context, session models.Session) error {
+	const op = "postgres.saveSession"
+
+	queryInsert := `
+		INSERT INTO sessions (id, owner_id, app_id, org_id, ip, user_agent)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)
+    `
+
+	_, err := s.pool.Exec(ctx, queryInsert,
+		session.Id, session.OwnerId, session.AppId, session.OrgId, session.Client.IP, session.Client.UserAgent,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save session: %w", op, err)
+	}
+
+	s.log.Info("Session created successfully",
+		slog.Int64("owner_id", session.OwnerId),
+		slog.String("session_id", session.Id),
+	)
+
+	return nil
+}
+
+func (s *Storage) GetSession(ctx context.Context, id string) (models.Session, error) {
+	query := `
+		SELECT id, owner_id, app_id, COALESCE(org_id, 0), ip, user_agent, created_at, last_seen, revoked_at
+		FROM sessions
+		WHERE id=$1
+	`
+
+	session, err := scanSession(s.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Session{}, fmt.Errorf("%w with id %s", storage.ErrSessionNotFound, id)
+		}
+		return models.Session{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions Returns the sessions of the owner which are not revoked, the most recent first
+func (s *Storage) ListSessions(ctx context.Context, ownerId int64) ([]models.Session, error) {
+	query := `
+		SELECT id, owner_id, app_id, COALESCE(org_id, 0), ip, user_agent, created_at, last_seen, revoked_at
+		FROM sessions
+		WHERE owner_id=$1 AND revoked_at IS NULL
+		ORDER BY last_seen DESC
+	`
+
+	rows, err := s.pool.Query(ctx, query, ownerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		session, errS := scanSession(rows)
+		if errS != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", errS)
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *Storage) TouchSession(ctx context.Context, id string, client models.ClientInfo) error {
+	query := `
+		UPDATE sessions
+		SET last_seen=now(), ip=$2, user_agent=$3
+		WHERE id=$1
+	`
+
+	if _, err := s.pool.Exec(ctx, query, id, client.IP, client.UserAgent); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession Revokes the session and every refresh token of its family
+func (s *Storage) RevokeSession(ctx context.Context, id string) error {
+	const op = "postgres.revokeSession"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		querySession := `
+			UPDATE sessions
+			SET revoked_at=now()
+			WHERE id=$1 AND revoked_at IS NULL
+		`
+		if _, err := tx.Exec(ctx, querySession, id); err != nil {
+			return err
+		}
+
+		queryTokens := `
+			UPDATE refresh_tokens
+			SET revoked_at=now()
+			WHERE family_id=$1 AND revoked_at IS NULL
+		`
+		_, err := tx.Exec(ctx, queryTokens, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke session: %w", op, err)
+	}
+
+	s.log.Info("Session revoked", slog.String("session_id", id))
+
+	return nil
+}
+
+// RevokeOwnerSessions Revokes every session of the owner except the given one, which may be empty
+func (s *Storage) RevokeOwnerSessions(ctx context.Context, ownerId int64, exceptId string) error {
+	const op = "postgres.revokeOwnerSessions"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		querySessions := `
+			UPDATE sessions
+			SET revoked_at=now()
+			WHERE owner_id=$1 AND id<>$2 AND revoked_at IS NULL
+		`
+		if _, err := tx.Exec(ctx, querySessions, ownerId, exceptId); err != nil {
+			return err
+		}
+
+		queryTokens := `
+			UPDATE refresh_tokens
+			SET revoked_at=now()
+			WHERE owner_id=$1 AND family_id<>$2 AND revoked_at IS NULL
+		`
+		_, err := tx.Exec(ctx, queryTokens, ownerId, exceptId)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke owner sessions: %w", op, err)
+	}
+
+	s.log.Info("Owner sessions revoked", slog.Int64("owner_id", ownerId))
+
+	return nil
+}
+
+// RevokeOrgSessions Revokes every session of the owner logged in to the organization
+func (s *Storage) RevokeOrgSessions(ctx context.Context, ownerId int64, orgId int64) error {
+	const op = "postgres.revokeOrgSessions"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		querySessions := `
+			UPDATE sessions
+			SET revoked_at=now()
+			WHERE owner_id=$1 AND org_id=$2 AND revoked_at IS NULL
+		`
+		if _, err := tx.Exec(ctx, querySessions, ownerId, orgId); err != nil {
+			return err
+		}
+
+		queryTokens := `
+			UPDATE refresh_tokens
+			SET revoked_at=now()
+			WHERE family_id IN (SELECT id FROM sessions WHERE owner_id=$1 AND org_id=$2)
+				AND revoked_at IS NULL
+		`
+		_, err := tx.Exec(ctx, queryTokens, ownerId, orgId)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke organization sessions: %w", op, err)
+	}
+
+	s.log.Info("Organization sessions revoked", slog.Int64("owner_id", ownerId), slog.Int64("org_id", orgId))
+
+	return nil
+}
+
+func scanSession(row pgx.Row) (models.Session, error) {
+	var session models.Session
+	err := row.Scan(
+		&session.Id, &session.OwnerId, &session.AppId, &session.OrgId, &session.Client.IP, &session.Client.UserAgent,
+		&session.CreatedAt, &session.LastSeen, &session.RevokedAt,
+	)
+	return session, err
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+)
+
+func (s *Storage) ListSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	query := `
+		SELECT id, algorithm, private_key, encrypted, state, created_at, retiring_at, retired_at
+		FROM signing_keys
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.SigningKey, 0)
+	for rows.Next() {
+		var key models.SigningKey
+		if err = rows.Scan(
+			&key.Id, &key.Algorithm, &key.PrivateKey, &key.Encrypted, &key.State,
+			&key.CreatedAt, &key.RetiringAt, &key.RetiredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RotateSigningKey Saves the key as active and moves the previous active key to retiring
+func (s *Storage) RotateSigningKey(ctx context.Context, key models.SigningKey) error {
+	const op = "postgres.rotateSigningKey"
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queryRetire := `
+			UPDATE signing_keys
+			SET state='retiring', retiring_at=now()
+			WHERE state='active'
+		`
+		if _, err := tx.Exec(ctx, queryRetire); err != nil {
+			return err
+		}
+
+		queryInsert := `
+			INSERT INTO signing_keys (id, algorithm, private_key, encrypted, state)
+			VALUES ($1, $2, $3, $4, 'active')
+		`
+		_, err := tx.Exec(ctx, queryInsert, key.Id, key.Algorithm, key.PrivateKey, key.Encrypted)
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, jwt.ErrSigningKeyConflict)
+		}
+		return fmt.Errorf("%s: failed to rotate signing key: %w", op, err)
+	}
+
+	s.log.Info("Signing key rotated successfully", slog.String("kid", key.Id))
+
+	return nil
+}
+
+// EncryptSigningKey Replaces a plaintext private key with the sealed one, an encrypted key is kept
+func (s *Storage) EncryptSigningKey(ctx context.Context, id string, sealed []byte) error {
+	query := `
+		UPDATE signing_keys
+		SET private_key=$2, encrypted=TRUE
+		WHERE id=$1 AND NOT encrypted
+	`
+
+	if _, err := s.pool.Exec(ctx, query, id, sealed); err != nil {
+		return fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	s.log.Info("Signing key encrypted", slog.String("kid", id))
+
+	return nil
+}
+
+// RetireSigningKeys Retires keys which have been retiring since before the given time
+func (s *Storage) RetireSigningKeys(ctx context.Context, retiringBefore time.Time) (int64, error) {
+	query := `
+		UPDATE signing_keys
+		SET state='retired', retired_at=now()
+		WHERE state='retiring' AND retiring_at <= $1
+	`
+
+	commandTag, err := s.pool.Exec(ctx, query, retiringBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+
+	if commandTag.RowsAffected() > 0 {
+		s.log.Info("Signing keys retired", slog.Int64("count", commandTag.RowsAffected()))
+	}
+
+	return commandTag.RowsAffected(), nil
+}
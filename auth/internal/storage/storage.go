@@ -5,4 +5,34 @@ import "errors"
 var (
 	ErrOwnerExists   = errors.New("owner already exists")
 	ErrOwnerNotFound = errors.New("owner not found")
+
+	ErrAppExists   = errors.New("app already exists")
+	ErrAppNotFound = errors.New("app not found")
+
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+
+	ErrRevokedTokenNotFound = errors.New("revoked token not found")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrTOTPNotFound         = errors.New("totp not found")
+	ErrTOTPConfirmed        = errors.New("totp already confirmed")
+	ErrTOTPStepUsed         = errors.New("totp code already used")
+	ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+	ErrMFAChallengeUsed     = errors.New("mfa challenge already used")
+	ErrRecoveryCodeUsed     = errors.New("recovery code already used")
+
+	ErrPasswordResetNotFound = errors.New("password reset not found")
+	ErrPasswordResetUsed     = errors.New("password reset already used")
+
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrRoleNotAssigned = errors.New("role not assigned")
+
+	ErrOrganizationExists   = errors.New("organization already exists")
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberExists         = errors.New("member already exists")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrExternalIdExists     = errors.New("external id already used in organization")
+	ErrLastOrgOwner         = errors.New("organization must keep an owner")
 )
@@ -1,3 +1,3 @@
 DROP TABLE IF EXISTS owners;
 
-DROP TABLE IF EXISTS app;
+DROP TABLE IF EXISTS apps;
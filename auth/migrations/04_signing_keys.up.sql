@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL,
+    private_key BYTEA NOT NULL,
+    state TEXT NOT NULL CHECK (state IN ('active', 'retiring', 'retired')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    retiring_at TIMESTAMPTZ,
+    retired_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_single_active ON signing_keys(state) WHERE state = 'active';
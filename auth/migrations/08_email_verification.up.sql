@@ -0,0 +1,5 @@
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Owners registered before verification existed keep logging in when it is required,
+-- only the owners registered from now on verify their email
+UPDATE owners SET email_verified = TRUE;
@@ -0,0 +1 @@
+ALTER TABLE owners DROP COLUMN IF EXISTS password_changed_at;
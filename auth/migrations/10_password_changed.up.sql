@@ -0,0 +1 @@
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS pepper_version INTEGER NOT NULL DEFAULT 0;
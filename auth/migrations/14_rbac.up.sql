@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS owner_roles (
+    owner_id INTEGER NOT NULL REFERENCES owners(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (owner_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_owner_roles_role ON owner_roles(role_id);
+
+INSERT INTO permissions (name) VALUES
+    ('owners.read'),
+    ('owners.write'),
+    ('owners.delete'),
+    ('owners.unlock'),
+    ('roles.read'),
+    ('roles.write')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Manages owners and their roles'),
+    ('viewer', 'Reads owners and roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin'
+   OR (r.name = 'viewer' AND p.name IN ('owners.read', 'roles.read'))
+ON CONFLICT DO NOTHING;
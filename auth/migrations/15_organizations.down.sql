@@ -0,0 +1,4 @@
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS org_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
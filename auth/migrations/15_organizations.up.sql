@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    owner_id INTEGER NOT NULL REFERENCES owners(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    external_id TEXT,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (org_id, owner_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_owner ON organization_members(owner_id);
+-- The id of the member in the systems of the organization is unique within it only
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_members_external_id
+    ON organization_members(org_id, external_id) WHERE external_id IS NOT NULL;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
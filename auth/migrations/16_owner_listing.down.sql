@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_owners_email_pattern;
+DROP INDEX IF EXISTS idx_owners_login_pattern;
+DROP INDEX IF EXISTS idx_owners_email_id;
+DROP INDEX IF EXISTS idx_owners_login_id;
+DROP INDEX IF EXISTS idx_owners_created_at_id;
+ALTER TABLE owners DROP COLUMN IF EXISTS created_at;
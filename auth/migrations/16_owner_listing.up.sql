@@ -0,0 +1,10 @@
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Keyset pages are ordered by the sort column with the id as the tie breaker
+CREATE INDEX IF NOT EXISTS idx_owners_created_at_id ON owners(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_owners_login_id ON owners(login, id);
+CREATE INDEX IF NOT EXISTS idx_owners_email_id ON owners(email, id);
+
+-- Prefix filters use LIKE, which plain indexes serve under the C collation only
+CREATE INDEX IF NOT EXISTS idx_owners_login_pattern ON owners(login text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_owners_email_pattern ON owners(email text_pattern_ops);
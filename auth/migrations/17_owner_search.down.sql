@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_owners_email_trgm;
+DROP INDEX IF EXISTS idx_owners_login_trgm;
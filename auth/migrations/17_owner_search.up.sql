@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve the similarity operators and substring matches of the owner search
+CREATE INDEX IF NOT EXISTS idx_owners_login_trgm ON owners USING gin (login gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_owners_email_trgm ON owners USING gin (email gin_trgm_ops);
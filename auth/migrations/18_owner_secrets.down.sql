@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name = 'owners.read_secrets';
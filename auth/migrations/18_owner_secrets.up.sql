@@ -0,0 +1,7 @@
+INSERT INTO permissions (name) VALUES ('owners.read_secrets')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'owners.read_secrets'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- NULL when the owner has not set a display name or has cleared it
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS display_name TEXT;
@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name IN ('apps.read', 'apps.write', 'keys.read', 'keys.write');
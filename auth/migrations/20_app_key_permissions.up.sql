@@ -0,0 +1,11 @@
+INSERT INTO permissions (name) VALUES
+    ('apps.read'),
+    ('apps.write'),
+    ('keys.read'),
+    ('keys.write')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name IN ('apps.read', 'apps.write', 'keys.read', 'keys.write')
+ON CONFLICT DO NOTHING;
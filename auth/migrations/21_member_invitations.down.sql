@@ -0,0 +1,2 @@
+DELETE FROM organization_members WHERE accepted_at IS NULL;
+ALTER TABLE organization_members DROP COLUMN IF EXISTS accepted_at;
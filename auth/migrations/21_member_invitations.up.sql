@@ -0,0 +1,3 @@
+-- NULL while the invited owner has not accepted the invitation, members added before invitations are accepted
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMPTZ;
+UPDATE organization_members SET accepted_at = joined_at WHERE accepted_at IS NULL;
@@ -0,0 +1,9 @@
+DELETE FROM owners WHERE org_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_owners_scope_email;
+DROP INDEX IF EXISTS idx_owners_scope_login;
+ALTER TABLE owners ADD CONSTRAINT owners_login_key UNIQUE (login);
+ALTER TABLE owners ADD CONSTRAINT owners_email_key UNIQUE (email);
+
+ALTER TABLE owners DROP COLUMN IF EXISTS org_id;
+ALTER TABLE organizations DROP COLUMN IF EXISTS scoped_accounts;
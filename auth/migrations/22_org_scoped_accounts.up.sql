@@ -0,0 +1,9 @@
+-- An organization with scoped accounts has owners of its own, their logins and emails are unique
+-- within the organization only. Owners without an organization keep them unique among themselves
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS scoped_accounts BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE owners DROP CONSTRAINT IF EXISTS owners_email_key;
+ALTER TABLE owners DROP CONSTRAINT IF EXISTS owners_login_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_owners_scope_login ON owners(COALESCE(org_id, 0), login);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_owners_scope_email ON owners(COALESCE(org_id, 0), email);
@@ -0,0 +1,3 @@
+-- Encrypted keys can't be read without the column, the ring creates a new key on load
+DELETE FROM signing_keys WHERE encrypted;
+ALTER TABLE signing_keys DROP COLUMN IF EXISTS encrypted;
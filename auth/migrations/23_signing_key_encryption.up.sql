@@ -0,0 +1,2 @@
+-- Private keys are sealed by the key ring, keys stored before are encrypted when the ring loads
+ALTER TABLE signing_keys ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
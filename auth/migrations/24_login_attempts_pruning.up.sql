@@ -0,0 +1,2 @@
+-- Counters are pruned once their last failure leaves the window
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts (last_failure_at);
@@ -0,0 +1,8 @@
+INSERT INTO permissions (name) VALUES
+    ('tokens.introspect')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'tokens.introspect'
+ON CONFLICT DO NOTHING;
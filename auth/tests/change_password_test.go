@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/grpc/ownerCtl"
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestChangePassword_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	// iat has a second precision, tokens of the same second as the change are accepted
+	time.Sleep(time.Second)
+
+	newPassword := generateValidPassword()
+	_, err := s.OwnerClient.ChangePassword(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ChangePasswordRequest{
+		CurrentPassword: owner.password,
+		NewPassword:     newPassword,
+	})
+	require.NoError(t, err, "failed change password")
+
+	introspect, errIT := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: owner.tokens.GetToken(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.False(t, introspect.GetActive(), "token issued before the change must be inactive")
+
+	_, err = s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.NoError(t, err, "current session must keep refreshing")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.Error(t, err, "expected error when logging in with the old password")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: newPassword,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login with the new password")
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.ChangePassword(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ChangePasswordRequest{
+		CurrentPassword: owner.password + "wrong",
+		NewPassword:     generateValidPassword(),
+	})
+	require.Error(t, err, "expected error for a wrong current password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
+
+func TestChangePassword_WrongCurrentPasswordThrottled(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.LoginLimit.BackoffAfter == 0 {
+		t.Skip("login backoff is disabled by the config")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	for i := 0; i < s.Cfg.LoginLimit.BackoffAfter; i++ {
+		_, err := s.OwnerClient.ChangePassword(ctx, &authv1.ChangePasswordRequest{
+			CurrentPassword: owner.password + "wrong",
+			NewPassword:     generateValidPassword(),
+		})
+		require.Error(t, err, "expected error for a wrong current password")
+		st, _ := status.FromError(err)
+		require.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	}
+
+	_, err := s.OwnerClient.ChangePassword(ctx, &authv1.ChangePasswordRequest{
+		CurrentPassword: owner.password,
+		NewPassword:     generateValidPassword(),
+	})
+	require.Error(t, err, "expected error once the guesses are throttled")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code(), "expected status code ResourceExhausted")
+}
+
+func TestChangePassword_MissingToken(t *testing.T) {
+	s := suite.New(t)
+
+	_, err := s.OwnerClient.ChangePassword(s.Ctx, &authv1.ChangePasswordRequest{
+		CurrentPassword: generateValidPassword(),
+		NewPassword:     generateValidPassword(),
+	})
+	require.Error(t, err, "expected error without a bearer token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func TestChangePassword_ReusedPassword(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.PasswordPolicy.HistorySize < 2 {
+		t.Skip("password history is not configured")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	_, err := s.OwnerClient.ChangePassword(ctx, &authv1.ChangePasswordRequest{
+		CurrentPassword: owner.password,
+		NewPassword:     owner.password,
+	})
+	require.Error(t, err, "expected error when keeping the current password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	assert.Equal(t, ownerCtl.PasswordReusedReason, errorInfoReason(st), "expected reused reason")
+
+	got, errGO := s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, errGO, "failed get owner")
+
+	// The token of the owner stops verifying after the update, the admin updates it
+	admin := adminContext(s, t)
+	newPassword := generateValidPassword()
+	_, err = s.OwnerClient.UpdateOwner(admin, &authv1.UpdateOwnerRequest{
+		Id:       got.GetId(),
+		Password: newPassword,
+	})
+	require.NoError(t, err, "failed update password")
+
+	_, err = s.OwnerClient.UpdateOwner(admin, &authv1.UpdateOwnerRequest{
+		Id:       got.GetId(),
+		Password: owner.password,
+	})
+	require.Error(t, err, "expected error when going back to a previous password")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	assert.Equal(t, ownerCtl.PasswordReusedReason, errorInfoReason(st), "expected reused reason")
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestSendVerificationEmail_NoEnumeration(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	existing, err := s.OwnerClient.SendVerificationEmail(s.Ctx, &authv1.SendVerificationEmailRequest{
+		Login: owner.login,
+	})
+	require.NoError(t, err, "failed send verification email")
+
+	unknown, err := s.OwnerClient.SendVerificationEmail(s.Ctx, &authv1.SendVerificationEmailRequest{
+		Login: gofakeit.Username() + "unknown",
+	})
+	require.NoError(t, err, "unknown login is not an error")
+	assert.Equal(t, existing.GetMessage(), unknown.GetMessage(), "responses must not reveal the login")
+
+	got, errGO := s.OwnerClient.GetOwner(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.GetOwnerRequest{Login: owner.login},
+	)
+	require.NoError(t, errGO, "failed get owner")
+	assert.False(t, got.GetEmailVerified(), "email is not verified yet")
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	s := suite.New(t)
+
+	_, err := s.OwnerClient.VerifyEmail(s.Ctx, &authv1.VerifyEmailRequest{Token: "garbage"})
+	require.Error(t, err, "expected error for an invalid token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
+
+func TestVerifyEmail_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.SendVerificationEmail(s.Ctx, &authv1.SendVerificationEmailRequest{
+		Login: owner.login,
+	})
+	require.NoError(t, err, "failed send verification email")
+
+	token := outboxToken(s, t, owner.email, s.Cfg.Email.VerificationURL)
+
+	_, err = s.OwnerClient.VerifyEmail(s.Ctx, &authv1.VerifyEmailRequest{Token: token})
+	require.NoError(t, err, "failed verify email")
+
+	_, err = s.OwnerClient.VerifyEmail(s.Ctx, &authv1.VerifyEmailRequest{Token: token})
+	require.Error(t, err, "expected error for a used token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner with verified email")
+
+	got, errGO := s.OwnerClient.GetOwner(
+		withBearer(s.Ctx, res.GetToken()), &authv1.GetOwnerRequest{Login: owner.login},
+	)
+	require.NoError(t, errGO, "failed get owner")
+	assert.True(t, got.GetEmailVerified(), "email is verified")
+}
+
+// outboxToken Waits for a message to the address in the outbox of the file sender
+// and returns the token following tokenURL in the newest one.
+// The outbox is resolved like the config path of the suite, relative to the directory of the server
+func outboxToken(s *suite.Suite, t *testing.T, to string, tokenURL string) string {
+	t.Helper()
+
+	require.Equal(t, "file", s.Cfg.Email.Sender, "tests read emails from the outbox of the file sender")
+	require.NotEmpty(t, tokenURL, "token url is not configured")
+
+	var tokens []string
+	require.Eventually(t, func() bool {
+		tokens = outboxTokens(t, s.Cfg.Email.OutboxDir, to, tokenURL)
+		return len(tokens) > 0
+	}, 5*time.Second, 50*time.Millisecond, "no email to %s in the outbox", to)
+
+	return tokens[0]
+}
+
+// outboxTokens Returns the tokens following tokenURL in the messages to the address, the newest first
+func outboxTokens(t *testing.T, dir string, to string, tokenURL string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "failed read outbox")
+
+	var tokens []string
+
+	// Messages are named by the time they were sent
+	slices.Reverse(entries)
+	for _, entry := range entries {
+		data, errRF := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, errRF, "failed read message")
+
+		lines := strings.Split(string(data), "\r\n")
+		if !slices.Contains(lines, "To: "+to) {
+			continue
+		}
+		for _, line := range lines {
+			if token, ok := strings.CutPrefix(line, tokenURL); ok {
+				tokens = append(tokens, token)
+				break
+			}
+		}
+	}
+
+	return tokens
+}
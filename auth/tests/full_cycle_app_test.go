@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestFullCycleApp_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	admin := adminContext(s, t)
+
+	name := generateAppName()
+	created := createAppAndCheckSuccess(s, t, name)
+
+	got, errGA := s.AppClient.GetApp(admin, &authv1.GetAppRequest{Name: name})
+	require.NoError(t, errGA, "failed get app")
+	assert.Equal(t, created.GetId(), got.GetId(), "app id")
+	assert.Empty(t, got.GetSecret(), "secret must not be exposed by GetApp")
+
+	list, errLA := s.AppClient.ListApps(admin, &authv1.ListAppsRequest{})
+	require.NoError(t, errLA, "failed list apps")
+	assert.NotEmpty(t, list.GetApps(), "apps list")
+
+	newName := generateAppName()
+	_, errUA := s.AppClient.UpdateApp(admin, &authv1.UpdateAppRequest{Id: created.GetId(), Name: newName})
+	require.NoError(t, errUA, "failed update app")
+
+	rotated, errRS := s.AppClient.RotateAppSecret(admin, &authv1.RotateAppSecretRequest{Id: created.GetId()})
+	require.NoError(t, errRS, "failed rotate app secret")
+	assert.Equal(t, newName, rotated.GetName(), "app name after update")
+	assert.NotEqual(t, created.GetSecret(), rotated.GetSecret(), "rotated secret")
+
+	_, errDA := s.AppClient.DeleteApp(admin, &authv1.DeleteAppRequest{Id: created.GetId()})
+	require.NoError(t, errDA, "failed delete app")
+
+	_, errGD := s.AppClient.GetApp(admin, &authv1.GetAppRequest{Id: created.GetId()})
+	require.Error(t, errGD, "expected error when getting deleted app")
+	st, _ := status.FromError(errGD)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+}
+
+func generateAppName() string {
+	return gofakeit.LetterN(12)
+}
+
+func TestApp_RequiresAdmin(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+
+	_, err := s.AppClient.RotateAppSecret(s.Ctx, &authv1.RotateAppSecretRequest{Id: app.GetId()})
+	require.Error(t, err, "expected error without a bearer token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err = s.AppClient.CreateApp(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.CreateAppRequest{Name: generateAppName()},
+	)
+	require.Error(t, err, "expected error when an owner creates an app")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+}
+
+func createAppAndCheckSuccess(s *suite.Suite, t *testing.T, name string) *authv1.App {
+	res, err := s.AppClient.CreateApp(adminContext(s, t), &authv1.CreateAppRequest{Name: name})
+
+	require.NoError(t, err, "failed create app "+name)
+	assert.NotZero(t, res.GetId(), "app id")
+	assert.NotEmpty(t, res.GetSecret(), "app secret")
+
+	return res
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -26,15 +27,16 @@ func TestFullCycleOwner_HappyPath(t *testing.T) {
 	password := generateValidPassword()
 	createOwnerAndCheckSuccess(s, t, login, email, password)
 
-	ownerID := getOwnerAndCheckSuccess(s, t, login, email, password)
+	ctx := adminContext(s, t)
+	ownerID := getOwnerAndCheckSuccess(s, t, ctx, login, email, password)
 
 	newLogin := gofakeit.Username()
 	newEmail, errGVEN := generateValidEmail(1000)
 	assert.NoError(t, errGVEN, "email generate failed")
 	newPassword := generateValidPassword()
-	updateOwnerAndCheckSuccess(s, t, ownerID, newLogin, newEmail, newPassword)
+	updateOwnerAndCheckSuccess(s, t, ctx, ownerID, newLogin, newEmail, newPassword)
 
-	deleteOwnerAndCheckSuccess(s, t, ownerID)
+	deleteOwnerAndCheckSuccess(s, t, ctx, ownerID)
 }
 
 func generateValidPassword() string {
@@ -63,8 +65,10 @@ func createOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, login, email, pass
 	assert.NotEmpty(t, res.GetMessage(), "create response message")
 }
 
-func getOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, login, email, password string) int64 {
-	res, errGO := s.OwnerClient.GetOwner(s.Ctx, &authv1.GetOwnerRequest{
+func getOwnerAndCheckSuccess(
+	s *suite.Suite, t *testing.T, ctx context.Context, login, email, password string,
+) int64 {
+	res, errGO := s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{
 		Login: login,
 	})
 
@@ -80,10 +84,10 @@ func getOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, login, email, passwor
 }
 
 func updateOwnerAndCheckSuccess(
-	s *suite.Suite, t *testing.T,
+	s *suite.Suite, t *testing.T, ctx context.Context,
 	id int64, newLogin, newEmail, newPassword string,
 ) {
-	res, err := s.OwnerClient.UpdateOwner(s.Ctx, &authv1.UpdateOwnerRequest{
+	res, err := s.OwnerClient.UpdateOwner(ctx, &authv1.UpdateOwnerRequest{
 		Id:       id,
 		Login:    newLogin,
 		Email:    newEmail,
@@ -93,11 +97,11 @@ func updateOwnerAndCheckSuccess(
 	require.NoError(t, err, "failed update")
 	assert.NotEmpty(t, res.GetMessage())
 
-	getOwnerAndCheckSuccess(s, t, newLogin, newEmail, newPassword)
+	getOwnerAndCheckSuccess(s, t, ctx, newLogin, newEmail, newPassword)
 }
 
-func deleteOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, id int64) {
-	res, err := s.OwnerClient.DeleteOwner(s.Ctx, &authv1.DeleteOwnerRequest{
+func deleteOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, ctx context.Context, id int64) {
+	res, err := s.OwnerClient.DeleteOwner(ctx, &authv1.DeleteOwnerRequest{
 		Id: id,
 	})
 
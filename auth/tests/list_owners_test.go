@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestListOwners_Pagination(t *testing.T) {
+	s := suite.New(t)
+
+	admin := adminContext(s, t)
+
+	prefix := "lst" + strings.ToLower(gofakeit.LetterN(10))
+	logins := []string{prefix + "a", prefix + "b", prefix + "c"}
+	for _, login := range logins {
+		email, err := generateValidEmail(1000)
+		require.NoError(t, err, "email generate failed")
+		createOwnerAndCheckSuccess(s, t, login, email, generateValidPassword())
+	}
+
+	listed := make([]string, 0, len(logins))
+	pageToken := ""
+	for page := 0; page < len(logins); page++ {
+		res, err := s.OwnerClient.ListOwners(admin, &authv1.ListOwnersRequest{
+			PageSize:    2,
+			PageToken:   pageToken,
+			LoginPrefix: prefix,
+			SortBy:      authv1.OwnerSortField_OWNER_SORT_LOGIN,
+			Descending:  true,
+		})
+		require.NoError(t, err, "failed list owners")
+
+		for _, owner := range res.GetOwners() {
+			assert.Nil(t, owner.GetSecrets(), "secrets")
+			listed = append(listed, owner.GetLogin())
+		}
+
+		pageToken = res.GetNextPageToken()
+		if pageToken == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{logins[2], logins[1], logins[0]}, listed, "owners in descending login order")
+
+	verified := true
+	res, err := s.OwnerClient.ListOwners(admin, &authv1.ListOwnersRequest{
+		LoginPrefix: prefix, EmailVerified: &verified,
+	})
+	require.NoError(t, err, "failed list verified owners")
+	assert.Empty(t, res.GetOwners(), "new owners have not verified their email")
+}
+
+func TestListOwners_Invalid(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.ListOwners(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ListOwnersRequest{})
+	require.Error(t, err, "expected error without the permission")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	admin := adminContext(s, t)
+
+	res, err := s.OwnerClient.ListOwners(admin, &authv1.ListOwnersRequest{PageSize: 1})
+	require.NoError(t, err, "failed list owners")
+	require.NotEmpty(t, res.GetNextPageToken(), "next page token")
+
+	_, err = s.OwnerClient.ListOwners(admin, &authv1.ListOwnersRequest{
+		PageSize: 1, PageToken: res.GetNextPageToken(), SortBy: authv1.OwnerSortField_OWNER_SORT_EMAIL,
+	})
+	require.Error(t, err, "expected error on a page token of another order")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
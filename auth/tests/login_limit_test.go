@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestLoginOwner_BackoffAndUnlock(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	for i := 0; i < s.Cfg.LoginLimit.BackoffAfter; i++ {
+		_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+			Login:    owner.login,
+			Password: owner.password + "wrong",
+			AppId:    app.GetId(),
+		})
+		require.Error(t, err, "expected error for a wrong password")
+	}
+
+	_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.Error(t, err, "expected the login to be blocked even with the right password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code(), "expected status code ResourceExhausted")
+
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	require.NotNil(t, retry, "expected retry info details")
+	assert.Positive(t, retry.GetRetryDelay().AsDuration(), "retry delay")
+
+	_, err = s.OwnerClient.UnlockOwner(adminContext(s, t), &authv1.UnlockOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "failed unlock owner")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login after unlock")
+}
+
+func TestLoginOwner_ConcurrentGuesses(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.LoginLimit.BackoffAfter == 0 {
+		t.Skip("login backoff is disabled by the config")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	guesses := s.Cfg.LoginLimit.BackoffAfter * 4
+	codesCh := make(chan codes.Code, guesses)
+
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+				Login:    owner.login,
+				Password: owner.password + "wrong",
+				AppId:    app.GetId(),
+			})
+			st, _ := status.FromError(err)
+			codesCh <- st.Code()
+		}()
+	}
+	wg.Wait()
+	close(codesCh)
+
+	// Guesses are counted before their passwords are checked, so no more of them are checked than the backoff allows
+	checked := 0
+	for code := range codesCh {
+		if code == codes.InvalidArgument {
+			checked++
+		} else {
+			assert.Equal(t, codes.ResourceExhausted, code, "expected status code ResourceExhausted")
+		}
+	}
+	assert.LessOrEqual(t, checked, s.Cfg.LoginLimit.BackoffAfter, "checked guesses")
+}
+
+func TestUnlockOwner_UnknownOwner(t *testing.T) {
+	s := suite.New(t)
+
+	_, err := s.OwnerClient.UnlockOwner(adminContext(s, t), &authv1.UnlockOwnerRequest{Id: 999999999})
+	require.Error(t, err, "expected error for an unknown owner")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestLoginOwner_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+
+	login := gofakeit.Username()
+	email, errGVE := generateValidEmail(1000)
+	assert.NoError(t, errGVE, "email generate failed")
+	password := generateValidPassword()
+	createOwnerAndCheckSuccess(s, t, login, email, password)
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    login,
+		Password: password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+
+	token, errP := jwt.Parse(res.GetToken(), func(token *jwt.Token) (interface{}, error) {
+		return []byte(app.GetSecret()), nil
+	})
+	require.NoError(t, errP, "token must be signed with the app secret")
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	require.True(t, ok, "map claims")
+	assert.Equal(t, login, claims["login"], "login claim")
+	assert.Equal(t, email, claims["email"], "email claim")
+	assert.EqualValues(t, app.GetId(), claims["app_id"], "app_id claim")
+}
+
+func TestLoginOwner_UnknownApp(t *testing.T) {
+	s := suite.New(t)
+
+	login := gofakeit.Username()
+	email, errGVE := generateValidEmail(1000)
+	assert.NoError(t, errGVE, "email generate failed")
+	password := generateValidPassword()
+	createOwnerAndCheckSuccess(s, t, login, email, password)
+
+	_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    login,
+		Password: password,
+		AppId:    999999,
+	})
+	require.Error(t, err, "expected error when logging in to unknown app")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    login,
+		Password: password,
+		AppId:    -1,
+	})
+	require.Error(t, err, "expected error when logging in with negative app id")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
+
+func TestIntrospectToken(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	otherApp := createAppAndCheckSuccess(s, t, generateAppName())
+
+	login := gofakeit.Username()
+	email, errGVE := generateValidEmail(1000)
+	assert.NoError(t, errGVE, "email generate failed")
+	password := generateValidPassword()
+	createOwnerAndCheckSuccess(s, t, login, email, password)
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    login,
+		Password: password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+
+	_, errNA := s.OwnerClient.IntrospectToken(s.Ctx, &authv1.IntrospectTokenRequest{Token: res.GetToken()})
+	require.Error(t, errNA, "expected error when introspecting without authentication")
+	st, _ := status.FromError(errNA)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+
+	_, errNP := s.OwnerClient.IntrospectToken(withBearer(s.Ctx, res.GetToken()), &authv1.IntrospectTokenRequest{
+		Token: res.GetToken(),
+	})
+	require.Error(t, errNP, "expected error when introspecting without the permission")
+	st, _ = status.FromError(errNP)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	admin := adminContext(s, t)
+
+	active, errIT := s.OwnerClient.IntrospectToken(admin, &authv1.IntrospectTokenRequest{
+		Token: res.GetToken(),
+		AppId: app.GetId(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.True(t, active.GetActive(), "token must be active")
+	assert.Equal(t, login, active.GetLogin(), "login claim")
+	assert.Equal(t, app.GetId(), active.GetAppId(), "app_id claim")
+	assert.NotZero(t, active.GetExp(), "exp claim")
+
+	replayed, errRP := s.OwnerClient.IntrospectToken(admin, &authv1.IntrospectTokenRequest{
+		Token: res.GetToken(),
+		AppId: otherApp.GetId(),
+	})
+	require.NoError(t, errRP, "failed introspect token")
+	assert.False(t, replayed.GetActive(), "token must be inactive for another app")
+
+	garbage, errG := s.OwnerClient.IntrospectToken(admin, &authv1.IntrospectTokenRequest{Token: "garbage"})
+	require.NoError(t, errG, "failed introspect token")
+	assert.False(t, garbage.GetActive(), "garbage token must be inactive")
+}
+
+type loggedOwner struct {
+	login    string
+	email    string
+	password string
+	tokens   *authv1.LoginResponse
+}
+
+func loginNewOwnerAndCheckSuccess(s *suite.Suite, t *testing.T, appId int32) loggedOwner {
+	login := gofakeit.Username()
+	email, errGVE := generateValidEmail(1000)
+	require.NoError(t, errGVE, "email generate failed")
+	password := generateValidPassword()
+	createOwnerAndCheckSuccess(s, t, login, email, password)
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    login,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err, "failed login owner")
+	require.NotEmpty(t, res.GetToken(), "access token")
+	require.NotEmpty(t, res.GetRefreshToken(), "refresh token")
+
+	return loggedOwner{login: login, email: email, password: password, tokens: res}
+}
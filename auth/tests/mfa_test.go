@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/totp"
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestMFA_TOTPLogin(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	secret := enrollTOTPAndCheckSuccess(s, t, owner.tokens.GetToken()).GetSecret()
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+	require.True(t, res.GetMfaRequired(), "expected mfa challenge")
+	assert.Empty(t, res.GetToken(), "no access token before mfa")
+	require.NotEmpty(t, res.GetMfaToken(), "mfa token")
+
+	_, err = s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: "000000"})
+	require.Error(t, err, "expected error for a wrong code")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+
+	// The code of the current step was spent by ConfirmTOTP, the next one is still accepted
+	code, _ := totp.Code(secret, totp.Step(time.Now())+1)
+	tokens, errVM := s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: code})
+	require.NoError(t, errVM, "failed verify mfa")
+	assert.NotEmpty(t, tokens.GetToken(), "access token")
+	assert.NotEmpty(t, tokens.GetRefreshToken(), "refresh token")
+
+	_, err = s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: code})
+	require.Error(t, err, "expected error when reusing the mfa token")
+}
+
+func TestMFA_DisableTOTP(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	secret := enrollTOTPAndCheckSuccess(s, t, owner.tokens.GetToken()).GetSecret()
+
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	_, err := s.OwnerClient.DisableTOTP(ctx, &authv1.DisableTOTPRequest{})
+	require.Error(t, err, "expected error without a code")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+
+	code, _ := totp.Code(secret, totp.Step(time.Now())+1)
+	_, err = s.OwnerClient.DisableTOTP(ctx, &authv1.DisableTOTPRequest{Code: code})
+	require.NoError(t, err, "failed disable totp")
+
+	res, errLO := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, errLO, "failed login owner")
+	assert.False(t, res.GetMfaRequired(), "mfa must not be required after disable")
+	assert.NotEmpty(t, res.GetToken(), "access token")
+}
+
+func TestMFA_RecoveryCode(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	enrolled := enrollTOTPAndCheckSuccess(s, t, owner.tokens.GetToken())
+	require.Len(t, enrolled.GetRecoveryCodes(), 10, "recovery codes")
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+	require.True(t, res.GetMfaRequired(), "expected mfa challenge")
+
+	recoveryCode := enrolled.GetRecoveryCodes()[0]
+	_, err = s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: recoveryCode})
+	require.NoError(t, err, "failed verify mfa with recovery code")
+
+	got, errGO := s.OwnerClient.GetOwner(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.GetOwnerRequest{Login: owner.login},
+	)
+	require.NoError(t, errGO, "failed get owner")
+	assert.True(t, got.GetMfaEnabled(), "mfa enabled")
+	assert.Equal(t, int32(9), got.GetRecoveryCodesRemaining(), "a recovery code is used once")
+
+	res, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+
+	_, err = s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: recoveryCode})
+	require.Error(t, err, "expected error when reusing a recovery code")
+
+	regenerated, errRRC := s.OwnerClient.RegenerateRecoveryCodes(
+		withBearer(s.Ctx, owner.tokens.GetToken()),
+		&authv1.RegenerateRecoveryCodesRequest{Code: enrolled.GetRecoveryCodes()[1]},
+	)
+	require.NoError(t, errRRC, "failed regenerate recovery codes")
+	assert.Len(t, regenerated.GetRecoveryCodes(), 10, "recovery codes")
+
+	got, errGO = s.OwnerClient.GetOwner(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.GetOwnerRequest{Login: owner.login},
+	)
+	require.NoError(t, errGO, "failed get owner")
+	assert.Equal(t, int32(10), got.GetRecoveryCodesRemaining(), "recovery codes are replaced")
+}
+
+func TestMFA_WrongCodesThrottled(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.LoginLimit.BackoffAfter == 0 {
+		t.Skip("login backoff is disabled by the config")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	enrollTOTPAndCheckSuccess(s, t, owner.tokens.GetToken())
+
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+	for i := 0; i < s.Cfg.LoginLimit.BackoffAfter-1; i++ {
+		_, err := s.OwnerClient.DisableTOTP(ctx, &authv1.DisableTOTPRequest{Code: "000000"})
+		require.Error(t, err, "expected error for a wrong code")
+		st, _ := status.FromError(err)
+		require.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	}
+
+	// A fresh challenge of a correct password keeps counting the wrong codes of the owner
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login owner")
+	require.True(t, res.GetMfaRequired(), "expected mfa challenge")
+
+	_, err = s.OwnerClient.VerifyMFA(s.Ctx, &authv1.VerifyMFARequest{MfaToken: res.GetMfaToken(), Code: "000000"})
+	require.Error(t, err, "expected error for a wrong code")
+
+	_, err = s.OwnerClient.DisableTOTP(ctx, &authv1.DisableTOTPRequest{Code: "000000"})
+	require.Error(t, err, "expected error once the codes are throttled")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code(), "expected status code ResourceExhausted")
+}
+
+// enrollTOTPAndCheckSuccess Enables TOTP of the token owner,
+// skips the test when the server has no mfa secret key
+func enrollTOTPAndCheckSuccess(s *suite.Suite, t *testing.T, accessToken string) *authv1.EnrollTOTPResponse {
+	ctx := withBearer(s.Ctx, accessToken)
+
+	enrolled, err := s.OwnerClient.EnrollTOTP(ctx, &authv1.EnrollTOTPRequest{})
+	if st, _ := status.FromError(err); st.Code() == codes.FailedPrecondition {
+		t.Skip("mfa is not configured on the server")
+	}
+	require.NoError(t, err, "failed enroll totp")
+	require.NotEmpty(t, enrolled.GetSecret(), "totp secret")
+	require.Contains(t, enrolled.GetUri(), "otpauth://totp/", "totp uri")
+
+	code, errC := totp.Code(enrolled.GetSecret(), totp.Step(time.Now()))
+	require.NoError(t, errC, "failed generate code")
+
+	_, err = s.OwnerClient.ConfirmTOTP(ctx, &authv1.ConfirmTOTPRequest{Code: code})
+	require.NoError(t, err, "failed confirm totp")
+
+	return enrolled
+}
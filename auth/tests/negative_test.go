@@ -51,7 +51,7 @@ func TestUpdateOwner_Failures(t *testing.T) {
 
 	newPassword := generateValidPassword()
 
-	_, err := s.OwnerClient.UpdateOwner(s.Ctx, &authv1.UpdateOwnerRequest{
+	_, err := s.OwnerClient.UpdateOwner(adminContext(s, t), &authv1.UpdateOwnerRequest{
 		Id:       id,
 		Login:    newLogin,
 		Email:    newEmail,
@@ -69,7 +69,7 @@ func TestDeleteOwner_Failures(t *testing.T) {
 	// Try deleting a non-existent owner
 	id := int64(99999) // Assume this ID does not exist
 
-	_, err := s.OwnerClient.DeleteOwner(s.Ctx, &authv1.DeleteOwnerRequest{
+	_, err := s.OwnerClient.DeleteOwner(adminContext(s, t), &authv1.DeleteOwnerRequest{
 		Id: id,
 	})
 
@@ -85,7 +85,7 @@ func TestGetOwner_Failures(t *testing.T) {
 	// Try getting a non-existent owner
 	login := "nonExistentLoginForTest"
 
-	_, err := s.OwnerClient.GetOwner(s.Ctx, &authv1.GetOwnerRequest{
+	_, err := s.OwnerClient.GetOwner(adminContext(s, t), &authv1.GetOwnerRequest{
 		Login: login,
 	})
 
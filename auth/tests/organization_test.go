@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestOrganization_MembersAndLogin(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	member := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	outsider := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	org, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{
+		Name: "Acme", Slug: generateOrgSlug(),
+	})
+	require.NoError(t, err, "failed create organization")
+	assert.Equal(t, "owner", org.GetRole(), "creator role")
+
+	_, err = s.OrgClient.InviteMember(ownerCtx, &authv1.InviteMemberRequest{
+		OrgId: org.GetId(), Login: member.login, ExternalId: "emp-1",
+	})
+	require.NoError(t, err, "failed invite member")
+
+	_, err = s.OrgClient.InviteMember(ownerCtx, &authv1.InviteMemberRequest{
+		OrgId: org.GetId(), Email: outsider.email, ExternalId: "emp-1",
+	})
+	require.Error(t, err, "expected error on a duplicate external id")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code(), "expected status code AlreadyExists")
+
+	// The invited owner is not a member until it accepts, and the organization does not see it yet
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: member.login, Password: member.password, AppId: app.GetId(), OrgId: org.GetId(),
+	})
+	require.Error(t, err, "expected error on login before accepting the invitation")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	members, err := s.OrgClient.ListMembers(ownerCtx, &authv1.ListMembersRequest{OrgId: org.GetId()})
+	require.NoError(t, err, "failed list members")
+	assert.Len(t, members.GetMembers(), 1, "invited owners are not listed")
+
+	memberCtx := withBearer(s.Ctx, member.tokens.GetToken())
+
+	invitations, err := s.OrgClient.ListOrganizations(memberCtx, &authv1.ListOrganizationsRequest{})
+	require.NoError(t, err, "failed list organizations")
+	require.Len(t, invitations.GetOrganizations(), 1, "invitations")
+	assert.True(t, invitations.GetOrganizations()[0].GetPending(), "invitation is pending")
+
+	_, err = s.OrgClient.ListMembers(memberCtx, &authv1.ListMembersRequest{OrgId: org.GetId()})
+	require.Error(t, err, "expected error when an invited owner lists members")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+
+	accepted, err := s.OrgClient.AcceptInvitation(memberCtx, &authv1.AcceptInvitationRequest{OrgId: org.GetId()})
+	require.NoError(t, err, "failed accept invitation")
+	assert.Equal(t, "member", accepted.GetRole(), "default role")
+	assert.False(t, accepted.GetPending(), "invitation is accepted")
+
+	_, err = s.OrgClient.AcceptInvitation(memberCtx, &authv1.AcceptInvitationRequest{OrgId: org.GetId()})
+	require.Error(t, err, "expected error when accepting twice")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+
+	_, err = s.OrgClient.ListMembers(
+		withBearer(s.Ctx, outsider.tokens.GetToken()), &authv1.ListMembersRequest{OrgId: org.GetId()},
+	)
+	require.Error(t, err, "expected error when an outsider lists members")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: outsider.login, Password: outsider.password, AppId: app.GetId(), OrgId: org.GetId(),
+	})
+	require.Error(t, err, "expected error on login to a foreign organization")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: member.login, Password: member.password, AppId: app.GetId(), OrgId: org.GetId(),
+	})
+	require.NoError(t, err, "failed login to organization")
+
+	introspect, err := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{Token: res.GetToken()})
+	require.NoError(t, err, "failed introspect token")
+	assert.Equal(t, org.GetId(), introspect.GetOrgId(), "org_id claim")
+	assert.Equal(t, "member", introspect.GetOrgRole(), "org_role claim")
+
+	members, err = s.OrgClient.ListMembers(ownerCtx, &authv1.ListMembersRequest{OrgId: org.GetId()})
+	require.NoError(t, err, "failed list members")
+	assert.Len(t, members.GetMembers(), 2, "members")
+
+	_, err = s.OrgClient.RemoveMember(ownerCtx, &authv1.RemoveMemberRequest{
+		OrgId: org.GetId(), OwnerId: introspect.GetUid(),
+	})
+	require.NoError(t, err, "failed remove member")
+
+	// The sessions of a removed member logged in to the organization are revoked
+	_, err = s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{RefreshToken: res.GetRefreshToken()})
+	require.Error(t, err, "expected error when refreshing after removal")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func TestOrganization_KeepsOwner(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	slug := generateOrgSlug()
+	org, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{Name: "Acme", Slug: slug})
+	require.NoError(t, err, "failed create organization")
+
+	_, err = s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{Name: "Other", Slug: slug})
+	require.Error(t, err, "expected error on a duplicate slug")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code(), "expected status code AlreadyExists")
+
+	orgs, err := s.OrgClient.ListOrganizations(ownerCtx, &authv1.ListOrganizationsRequest{})
+	require.NoError(t, err, "failed list organizations")
+	require.Len(t, orgs.GetOrganizations(), 1, "organizations")
+
+	owned, err := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{Token: owner.tokens.GetToken()})
+	require.NoError(t, err, "failed introspect token")
+
+	_, err = s.OrgClient.RemoveMember(ownerCtx, &authv1.RemoveMemberRequest{
+		OrgId: org.GetId(), OwnerId: owned.GetUid(),
+	})
+	require.Error(t, err, "expected error when the last owner leaves")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code(), "expected status code FailedPrecondition")
+}
+
+func TestOrganization_InviteUnknownOwner(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	org, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{
+		Name: "Acme", Slug: generateOrgSlug(),
+	})
+	require.NoError(t, err, "failed create organization")
+
+	email, err := generateValidEmail(1000)
+	require.NoError(t, err, "email generate failed")
+
+	// Inviting an account that does not exist answers as for an existing one
+	_, err = s.OrgClient.InviteMember(ownerCtx, &authv1.InviteMemberRequest{OrgId: org.GetId(), Email: email})
+	require.NoError(t, err, "invitation of an unknown owner is not reported")
+
+	members, err := s.OrgClient.ListMembers(ownerCtx, &authv1.ListMembersRequest{OrgId: org.GetId()})
+	require.NoError(t, err, "failed list members")
+	assert.Len(t, members.GetMembers(), 1, "members")
+}
+
+func TestOrganization_DeclineInvitation(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	invited := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+	invitedCtx := withBearer(s.Ctx, invited.tokens.GetToken())
+
+	org, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{
+		Name: "Acme", Slug: generateOrgSlug(),
+	})
+	require.NoError(t, err, "failed create organization")
+
+	_, err = s.OrgClient.InviteMember(ownerCtx, &authv1.InviteMemberRequest{
+		OrgId: org.GetId(), Login: invited.login, Role: "owner",
+	})
+	require.NoError(t, err, "failed invite member")
+
+	self, err := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{Token: invited.tokens.GetToken()})
+	require.NoError(t, err, "failed introspect token")
+
+	_, err = s.OrgClient.RemoveMember(invitedCtx, &authv1.RemoveMemberRequest{
+		OrgId: org.GetId(), OwnerId: self.GetUid(),
+	})
+	require.NoError(t, err, "failed decline invitation")
+
+	_, err = s.OrgClient.AcceptInvitation(invitedCtx, &authv1.AcceptInvitationRequest{OrgId: org.GetId()})
+	require.Error(t, err, "expected error when accepting a declined invitation")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "expected status code NotFound")
+}
+
+func TestOrganization_ScopedAccounts(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	org, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{
+		Name: "Acme", Slug: generateOrgSlug(), ScopedAccounts: true,
+	})
+	require.NoError(t, err, "failed create organization")
+	assert.True(t, org.GetScopedAccounts(), "scoped accounts")
+
+	// The login and the email of an owner without an organization are free within the organization
+	password := generateValidPassword()
+	created, err := s.OrgClient.CreateMember(ownerCtx, &authv1.CreateMemberRequest{
+		OrgId: org.GetId(), Login: owner.login, Email: owner.email, Password: password, ExternalId: "emp-1",
+	})
+	require.NoError(t, err, "failed create member")
+	assert.Equal(t, "member", created.GetRole(), "default role")
+
+	_, err = s.OrgClient.CreateMember(ownerCtx, &authv1.CreateMemberRequest{
+		OrgId: org.GetId(), Login: owner.login, Email: "other" + owner.email, Password: password,
+	})
+	require.Error(t, err, "expected error on a duplicate login within the organization")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code(), "expected status code AlreadyExists")
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: owner.login, Password: password, AppId: app.GetId(), OrgId: org.GetId(),
+	})
+	require.NoError(t, err, "failed login of the organization account")
+
+	introspect, err := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{Token: res.GetToken()})
+	require.NoError(t, err, "failed introspect token")
+	assert.Equal(t, created.GetOwnerId(), introspect.GetUid(), "the organization account logs in")
+	assert.Equal(t, org.GetId(), introspect.GetOrgId(), "org_id claim")
+
+	// Without the organization the login names the owner without one
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: owner.login, Password: password, AppId: app.GetId(),
+	})
+	require.Error(t, err, "expected error on login of the organization account without the organization")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+
+	_, err = s.OwnerClient.GetOwner(withBearer(s.Ctx, res.GetToken()), &authv1.GetOwnerRequest{Login: owner.login})
+	require.Error(t, err, "expected error when the organization account gets the owner with its login")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	flat, err := s.OrgClient.CreateOrganization(ownerCtx, &authv1.CreateOrganizationRequest{
+		Name: "Flat", Slug: generateOrgSlug(),
+	})
+	require.NoError(t, err, "failed create organization")
+
+	_, err = s.OrgClient.CreateMember(ownerCtx, &authv1.CreateMemberRequest{
+		OrgId: flat.GetId(), Login: owner.login, Email: owner.email, Password: password,
+	})
+	require.Error(t, err, "expected error on an account of an organization without scoped accounts")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code(), "expected status code FailedPrecondition")
+}
+
+func generateOrgSlug() string {
+	return "org-" + strings.ToLower(gofakeit.LetterN(12))
+}
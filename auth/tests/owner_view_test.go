@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestGetOwner_ReadMask(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	full, err := s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "failed get owner")
+	assert.Equal(t, owner.email, full.GetEmail(), "email")
+	assert.Nil(t, full.GetSecrets(), "secrets are returned only when asked for")
+
+	masked, err := s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{
+		Login: owner.login, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"id", "login"}},
+	})
+	require.NoError(t, err, "failed get owner with a read mask")
+	assert.Equal(t, owner.login, masked.GetLogin(), "login")
+	assert.Empty(t, masked.GetEmail(), "email is not in the mask")
+
+	_, err = s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{
+		Login: owner.login, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"secrets"}},
+	})
+	require.Error(t, err, "expected error when an owner reads its secrets")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	_, err = s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{
+		Login: owner.login, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"password_hash"}},
+	})
+	require.Error(t, err, "expected error on an unknown path")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+
+	secrets, err := s.OwnerClient.GetOwner(adminContext(s, t), &authv1.GetOwnerRequest{
+		Login: owner.login, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"login", "secrets.password_hash"}},
+	})
+	require.NoError(t, err, "failed get owner secrets as admin")
+	assert.NotEmpty(t, secrets.GetSecrets().GetPasswordHash(), "password hash")
+	assert.Empty(t, secrets.GetEmail(), "email is not in the mask")
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/grpc/ownerCtl"
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestGetPasswordPolicy(t *testing.T) {
+	s := suite.New(t)
+
+	policy, err := s.OwnerClient.GetPasswordPolicy(s.Ctx, &authv1.GetPasswordPolicyRequest{})
+	require.NoError(t, err, "failed get password policy")
+
+	cfg := s.Cfg.PasswordPolicy
+	assert.Equal(t, int32(cfg.MinLength), policy.GetMinLength(), "min length")
+	assert.Equal(t, int32(cfg.MaxLength), policy.GetMaxLength(), "max length")
+	assert.Equal(t, cfg.RequireDigit, policy.GetRequireDigit(), "require digit")
+	assert.Equal(t, cfg.ForbidPersonal, policy.GetForbidPersonal(), "forbid personal")
+	assert.Equal(t, cfg.ForbiddenSubstrings, policy.GetForbiddenSubstrings(), "forbidden substrings")
+	assert.Equal(t, cfg.BreachedList != "" || cfg.BreachedHIBPDir != "", policy.GetRejectBreached(), "reject breached")
+}
+
+func TestCreateOwner_PasswordWithLogin(t *testing.T) {
+	s := suite.New(t)
+	if !s.Cfg.PasswordPolicy.ForbidPersonal {
+		t.Skip("personal passwords are allowed by the config")
+	}
+
+	login := gofakeit.Username() + "policy"
+	email, err := generateValidEmail(5)
+	require.NoError(t, err, "failed generate email")
+
+	_, err = s.OwnerClient.CreateOwner(s.Ctx, &authv1.CreateOwnerRequest{
+		Login:    login,
+		Email:    email,
+		Password: "X1" + login,
+	})
+	require.Error(t, err, "expected error for a password containing the login")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
+
+func TestCreateOwner_BreachedPassword(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.PasswordPolicy.BreachedList == "" {
+		t.Skip("breached password list is not configured")
+	}
+
+	email, err := generateValidEmail(5)
+	require.NoError(t, err, "failed generate email")
+
+	_, err = s.OwnerClient.CreateOwner(s.Ctx, &authv1.CreateOwnerRequest{
+		Login:    gofakeit.Username() + "breached",
+		Email:    email,
+		Password: "qwerty123",
+	})
+	require.Error(t, err, "expected error for a common password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	assert.Equal(t, ownerCtl.PasswordBreachedReason, errorInfoReason(st), "expected breached reason")
+
+	_, err = s.OwnerClient.CreateOwner(s.Ctx, &authv1.CreateOwnerRequest{
+		Login:    gofakeit.Username() + "short",
+		Email:    email,
+		Password: "a1",
+	})
+	require.Error(t, err, "expected error for a short password")
+	st, _ = status.FromError(err)
+	assert.Equal(t, ownerCtl.PasswordPolicyReason, errorInfoReason(st), "expected policy reason")
+}
+
+func TestLoginOwner_PasswordNotCheckedByPolicy(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	// A password breaking the policy is only a wrong password at login
+	_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: owner.login, Password: "a1", AppId: app.GetId(),
+	})
+	require.Error(t, err, "expected error for a wrong password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+	assert.Equal(t, "invalid email or password", st.Message(), "expected wrong password message")
+	assert.Empty(t, errorInfoReason(st), "the policy is not revealed")
+}
+
+func TestLoginOwner_BreachedPasswordNotRevealed(t *testing.T) {
+	s := suite.New(t)
+	if s.Cfg.PasswordPolicy.BreachedList == "" {
+		t.Skip("breached password list is not configured")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	// Whether a password is listed is not told before the credentials are checked
+	_, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: owner.login, Password: "qwerty123", AppId: app.GetId(),
+	})
+	require.Error(t, err, "expected error for a wrong password")
+	st, _ := status.FromError(err)
+	assert.Equal(t, "invalid email or password", st.Message(), "expected wrong password message")
+	assert.NotEqual(t, ownerCtl.PasswordBreachedReason, errorInfoReason(st), "the breached list is not revealed")
+}
+
+func errorInfoReason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestRequestPasswordReset_NoEnumeration(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	byLogin, err := s.OwnerClient.RequestPasswordReset(s.Ctx, &authv1.RequestPasswordResetRequest{
+		Login: owner.login,
+	})
+	require.NoError(t, err, "failed request password reset by login")
+
+	byEmail, err := s.OwnerClient.RequestPasswordReset(s.Ctx, &authv1.RequestPasswordResetRequest{
+		Email: owner.email,
+	})
+	require.NoError(t, err, "failed request password reset by email")
+
+	unknown, err := s.OwnerClient.RequestPasswordReset(s.Ctx, &authv1.RequestPasswordResetRequest{
+		Login: gofakeit.Username() + "unknown",
+	})
+	require.NoError(t, err, "unknown login is not an error")
+
+	assert.Equal(t, byLogin.GetMessage(), unknown.GetMessage(), "responses must not reveal the login")
+	assert.Equal(t, byEmail.GetMessage(), unknown.GetMessage(), "responses must not reveal the email")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "password must not change before the reset is used")
+}
+
+func TestResetPassword_Invalid(t *testing.T) {
+	s := suite.New(t)
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+	}{
+		{"invalid token", "garbage", generateValidPassword()},
+		{"empty token", "", generateValidPassword()},
+		{"weak password", "garbage", "weak"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.OwnerClient.ResetPassword(s.Ctx, &authv1.ResetPasswordRequest{
+				Token:    tt.token,
+				Password: tt.password,
+			})
+			require.Error(t, err, "expected error")
+			st, _ := status.FromError(err)
+			assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+		})
+	}
+}
+
+func TestResetPassword_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	// iat has a second precision, tokens of the same second as the reset are accepted
+	time.Sleep(time.Second)
+
+	_, err := s.OwnerClient.RequestPasswordReset(s.Ctx, &authv1.RequestPasswordResetRequest{
+		Email: owner.email,
+	})
+	require.NoError(t, err, "failed request password reset")
+
+	token := outboxToken(s, t, owner.email, s.Cfg.Email.ResetURL)
+
+	newPassword := generateValidPassword()
+	_, err = s.OwnerClient.ResetPassword(s.Ctx, &authv1.ResetPasswordRequest{
+		Token:    token,
+		Password: newPassword,
+	})
+	require.NoError(t, err, "failed reset password")
+
+	_, err = s.OwnerClient.ResetPassword(s.Ctx, &authv1.ResetPasswordRequest{
+		Token:    token,
+		Password: generateValidPassword(),
+	})
+	require.Error(t, err, "expected error for a used token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+
+	introspect, errIT := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: owner.tokens.GetToken(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.False(t, introspect.GetActive(), "token issued before the reset must be inactive")
+
+	_, err = s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.Error(t, err, "expected error for a refresh token issued before the reset")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.Error(t, err, "expected error when logging in with the old password")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: newPassword,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed login with the new password")
+}
+
+func TestRequestPasswordReset_Limit(t *testing.T) {
+	s := suite.New(t)
+	limit := s.Cfg.Email.ResetLimit
+	if limit == 0 {
+		t.Skip("password resets are not limited by the config")
+	}
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	for i := 0; i < limit+2; i++ {
+		_, err := s.OwnerClient.RequestPasswordReset(s.Ctx, &authv1.RequestPasswordResetRequest{
+			Email: owner.email,
+		})
+		require.NoError(t, err, "requests past the limit look the same")
+	}
+
+	require.Eventually(t, func() bool {
+		return len(outboxTokens(t, s.Cfg.Email.OutboxDir, owner.email, s.Cfg.Email.ResetURL)) >= limit
+	}, 5*time.Second, 50*time.Millisecond, "expected %d reset emails", limit)
+
+	// Requests past the limit are dropped in the background, give them the time to be sent anyway
+	time.Sleep(time.Second)
+	assert.Len(t, outboxTokens(t, s.Cfg.Email.OutboxDir, owner.email, s.Cfg.Email.ResetURL), limit,
+		"reset emails past the limit")
+}
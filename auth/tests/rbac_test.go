@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/internal/domain/models"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/jwt"
+	"github.com/viacheslavek/grpcauth/auth/internal/lib/logger/handlers/slogdiscard"
+	"github.com/viacheslavek/grpcauth/auth/internal/storage/postgres"
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestOwnerAccess_RequiresPermission(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	other := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.GetOwner(s.Ctx, &authv1.GetOwnerRequest{Login: other.login})
+	require.Error(t, err, "expected error without a bearer token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	_, err = s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{Login: other.login})
+	require.Error(t, err, "expected error when reading another owner")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	_, err = s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "owners read themselves without a permission")
+
+	_, err = s.OwnerClient.AssignRole(ctx, &authv1.AssignRoleRequest{Login: owner.login, Role: "admin"})
+	require.Error(t, err, "expected error when assigning a role to oneself")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+}
+
+func TestAssignRole_HappyPath(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	other := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	admin := adminContext(s, t)
+
+	_, err := s.OwnerClient.AssignRole(admin, &authv1.AssignRoleRequest{Login: owner.login, Role: "viewer"})
+	require.NoError(t, err, "failed assign role")
+
+	roles, err := s.OwnerClient.ListRoles(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ListRolesRequest{Login: owner.login},
+	)
+	require.NoError(t, err, "owners list their own roles")
+	require.Len(t, roles.GetRoles(), 1, "roles")
+	assert.Equal(t, "viewer", roles.GetRoles()[0].GetName(), "role name")
+
+	// Roles reach the token claims when the tokens are refreshed
+	refreshed, err := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.NoError(t, err, "failed refresh token")
+	ctx := withBearer(s.Ctx, refreshed.GetToken())
+
+	_, err = s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{Login: other.login})
+	require.NoError(t, err, "viewer reads other owners")
+
+	_, err = s.OwnerClient.DeleteOwner(ctx, &authv1.DeleteOwnerRequest{Login: other.login})
+	require.Error(t, err, "expected error when a viewer deletes an owner")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	_, err = s.OwnerClient.RevokeRole(admin, &authv1.RevokeRoleRequest{Login: owner.login, Role: "viewer"})
+	require.NoError(t, err, "failed revoke role")
+
+	_, err = s.OwnerClient.RevokeRole(admin, &authv1.RevokeRoleRequest{Login: owner.login, Role: "viewer"})
+	require.Error(t, err, "expected error when revoking a role not assigned")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code(), "expected status code FailedPrecondition")
+
+	_, err = s.OwnerClient.AssignRole(admin, &authv1.AssignRoleRequest{Login: owner.login, Role: "unknown"})
+	require.Error(t, err, "expected error for an unknown role")
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
+
+func TestListRoles_All(t *testing.T) {
+	s := suite.New(t)
+
+	roles, err := s.OwnerClient.ListRoles(adminContext(s, t), &authv1.ListRolesRequest{})
+	require.NoError(t, err, "failed list roles")
+
+	names := make([]string, 0, len(roles.GetRoles()))
+	for _, role := range roles.GetRoles() {
+		names = append(names, role.GetName())
+	}
+	assert.Contains(t, names, "admin", "admin role")
+	assert.Contains(t, names, "viewer", "viewer role")
+}
+
+func TestOwnerAccess_ForgedToken(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	other := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	var claims jwt.Claims
+	_, _, err := gojwt.NewParser().ParseUnverified(owner.tokens.GetToken(), &claims)
+	require.NoError(t, err, "failed parse own token")
+
+	otherClaims, err := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: other.tokens.GetToken(),
+	})
+	require.NoError(t, err, "failed introspect token")
+
+	// The app secret signs HS256 tokens, a holder of it claims the admin role
+	tests := []struct {
+		name string
+		uid  int64
+		sid  string
+		code codes.Code
+	}{
+		{"without session", claims.Uid, "", codes.Unauthenticated},
+		{"session of another owner", otherClaims.GetUid(), claims.Sid, codes.Unauthenticated},
+		{"roles not assigned", claims.Uid, claims.Sid, codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forged := models.Owner{}
+			require.NoError(t, forged.SetId(tt.uid), "owner id")
+			forged.SetRoles([]string{models.RoleAdmin})
+
+			signer := models.App{}
+			require.NoError(t, signer.SetId(app.GetId()), "app id")
+			signer.SetSecret(app.GetSecret())
+
+			token, errNT := jwt.NewManager(jwt.StaticKeys{}, time.Time{}).NewToken(
+				forged, signer, models.Membership{}, tt.sid, time.Hour,
+			)
+			require.NoError(t, errNT, "failed sign token")
+
+			_, errLO := s.OwnerClient.ListOwners(withBearer(s.Ctx, token), &authv1.ListOwnersRequest{})
+			require.Error(t, errLO, "expected error with a forged token")
+			st, _ := status.FromError(errLO)
+			assert.Equal(t, tt.code, st.Code(), "expected status code")
+		})
+	}
+}
+
+// adminContext Logs in as the admin from the config to its app and returns the context with its token.
+// The server seeds the admin on startup, its password may come from RBAC_ADMIN_PASSWORD
+func adminContext(s *suite.Suite, t *testing.T) context.Context {
+	t.Helper()
+
+	require.NotEmpty(t, s.Cfg.RBAC.AdminLogin, "rbac admin_login is not configured")
+	require.NotEmpty(t, s.Cfg.RBAC.AdminPassword, "rbac admin_password or RBAC_ADMIN_PASSWORD is not configured")
+
+	res, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    s.Cfg.RBAC.AdminLogin,
+		Password: s.Cfg.RBAC.AdminPassword,
+		AppId:    adminAppId(s, t),
+	})
+	require.NoError(t, err, "failed login admin")
+
+	return withBearer(s.Ctx, res.GetToken())
+}
+
+// adminAppId Looks up the app the server creates for the admin on startup,
+// apps are created by admins only, so the admin can not create its own
+func adminAppId(s *suite.Suite, t *testing.T) int32 {
+	t.Helper()
+
+	db, err := postgres.New(s.Ctx, slogdiscard.NewDiscardLogger(), s.Cfg.DB)
+	require.NoError(t, err, "failed connect db")
+
+	app, err := db.GetApp(s.Ctx, models.AppKey{Name: s.Cfg.RBAC.AdminApp})
+	require.NoError(t, err, "failed get admin app")
+
+	return app.Id()
+}
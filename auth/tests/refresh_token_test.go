@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	rotated, err := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.NoError(t, err, "failed refresh token")
+	assert.NotEmpty(t, rotated.GetToken(), "access token")
+	assert.NotEqual(t, owner.tokens.GetRefreshToken(), rotated.GetRefreshToken(), "rotated refresh token")
+
+	next, errN := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: rotated.GetRefreshToken(),
+	})
+	require.NoError(t, errN, "failed refresh rotated token")
+	assert.NotEmpty(t, next.GetRefreshToken(), "refresh token")
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	rotated, err := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.NoError(t, err, "failed refresh token")
+
+	// Replay the already used token
+	_, errR := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.Error(t, errR, "expected error when reusing refresh token")
+	st, _ := status.FromError(errR)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+
+	// The legitimate successor is revoked together with the family
+	_, errS := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: rotated.GetRefreshToken(),
+	})
+	require.Error(t, errS, "expected error when using token of revoked family")
+	st, _ = status.FromError(errS)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestLogout_RevokesTokens(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.Logout(s.Ctx, &authv1.LogoutRequest{
+		Token:        owner.tokens.GetToken(),
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.NoError(t, err, "failed logout")
+
+	res, errIT := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: owner.tokens.GetToken(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.False(t, res.GetActive(), "token must be inactive after logout")
+
+	_, errRT := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: owner.tokens.GetRefreshToken(),
+	})
+	require.Error(t, errRT, "expected error when refreshing after logout")
+	st, _ := status.FromError(errRT)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func TestRevokeToken_DeletedOwner(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	_, err := s.OwnerClient.RevokeToken(s.Ctx, &authv1.RevokeTokenRequest{Token: "garbage"})
+	require.NoError(t, err, "revoking an invalid token is not an error")
+
+	ctx := withBearer(s.Ctx, owner.tokens.GetToken())
+	got, errGO := s.OwnerClient.GetOwner(ctx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, errGO, "failed get owner")
+	deleteOwnerAndCheckSuccess(s, t, ctx, got.GetId())
+
+	res, errIT := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: owner.tokens.GetToken(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.False(t, res.GetActive(), "token of deleted owner must be inactive")
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestSearchOwners_Misspelled(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	login := "srch" + strings.ToLower(gofakeit.LetterN(12))
+	email, err := generateValidEmail(1000)
+	require.NoError(t, err, "email generate failed")
+	createOwnerAndCheckSuccess(s, t, login, email, generateValidPassword())
+
+	// A swapped pair of letters in the middle of the login
+	misspelled := login[:8] + login[9:10] + login[8:9] + login[10:]
+
+	res, err := s.OwnerClient.SearchOwners(adminContext(s, t), &authv1.SearchOwnersRequest{Query: misspelled})
+	require.NoError(t, err, "failed search owners")
+	require.NotEmpty(t, res.GetMatches(), "matches")
+	assert.Equal(t, login, res.GetMatches()[0].GetOwner().GetLogin(), "best match")
+	assert.Greater(t, res.GetMatches()[0].GetScore(), float32(0), "score")
+
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	res, err = s.OwnerClient.SearchOwners(ownerCtx, &authv1.SearchOwnersRequest{Query: login})
+	require.NoError(t, err, "failed search owners without the permission")
+	assert.Empty(t, res.GetMatches(), "owners without the permission only find themselves")
+
+	res, err = s.OwnerClient.SearchOwners(ownerCtx, &authv1.SearchOwnersRequest{Query: owner.login})
+	require.NoError(t, err, "failed search oneself")
+	require.Len(t, res.GetMatches(), 1, "matches")
+	assert.Equal(t, owner.login, res.GetMatches()[0].GetOwner().GetLogin(), "own login")
+
+	_, err = s.OwnerClient.SearchOwners(ownerCtx, &authv1.SearchOwnersRequest{Query: "ab"})
+	require.Error(t, err, "expected error on a short query")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+}
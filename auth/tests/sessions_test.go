@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	second, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed second login")
+
+	res, errLS := s.OwnerClient.ListSessions(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ListSessionsRequest{})
+	require.NoError(t, errLS, "failed list sessions")
+	require.Len(t, res.GetSessions(), 2, "expected a session per login")
+
+	var other *authv1.Session
+	for _, session := range res.GetSessions() {
+		assert.Equal(t, app.GetId(), session.GetAppId(), "session app")
+		assert.NotEmpty(t, session.GetIp(), "session ip")
+		if !session.GetCurrent() {
+			other = session
+		}
+	}
+	require.NotNil(t, other, "expected exactly one current session")
+
+	_, err = s.OwnerClient.RevokeSession(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.RevokeSessionRequest{
+		SessionId: other.GetId(),
+	})
+	require.NoError(t, err, "failed revoke session")
+
+	introspect, errIT := s.OwnerClient.IntrospectToken(adminContext(s, t), &authv1.IntrospectTokenRequest{
+		Token: second.GetToken(),
+	})
+	require.NoError(t, errIT, "failed introspect token")
+	assert.False(t, introspect.GetActive(), "token of a revoked session must be inactive")
+
+	_, errRT := s.OwnerClient.RefreshToken(s.Ctx, &authv1.RefreshTokenRequest{
+		RefreshToken: second.GetRefreshToken(),
+	})
+	require.Error(t, errRT, "expected error when refreshing a revoked session")
+	st, _ := status.FromError(errRT)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func TestSessions_RevokeAllKeepsCurrent(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+
+	second, err := s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login:    owner.login,
+		Password: owner.password,
+		AppId:    app.GetId(),
+	})
+	require.NoError(t, err, "failed second login")
+
+	_, err = s.OwnerClient.RevokeAllSessions(
+		withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.RevokeAllSessionsRequest{KeepCurrent: true},
+	)
+	require.NoError(t, err, "failed revoke all sessions")
+
+	res, errLS := s.OwnerClient.ListSessions(withBearer(s.Ctx, owner.tokens.GetToken()), &authv1.ListSessionsRequest{})
+	require.NoError(t, errLS, "current session must stay valid")
+	require.Len(t, res.GetSessions(), 1, "expected only the current session")
+	assert.True(t, res.GetSessions()[0].GetCurrent(), "expected the current session")
+
+	_, err = s.OwnerClient.ListSessions(withBearer(s.Ctx, second.GetToken()), &authv1.ListSessionsRequest{})
+	require.Error(t, err, "expected error for a revoked session")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func TestSessions_Unauthenticated(t *testing.T) {
+	s := suite.New(t)
+
+	_, err := s.OwnerClient.ListSessions(s.Ctx, &authv1.ListSessionsRequest{})
+	require.Error(t, err, "expected error without a bearer token")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code(), "expected status code Unauthenticated")
+}
+
+func withBearer(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
@@ -20,6 +20,8 @@ type Suite struct {
 	Ctx         context.Context
 	Cfg         *config.Config
 	OwnerClient authv1.OwnerControllerClient
+	AppClient   authv1.AppControllerClient
+	OrgClient   authv1.OrganizationControllerClient
 }
 
 const (
@@ -61,5 +63,7 @@ func New(t *testing.T) *Suite {
 		Ctx:         ctx,
 		Cfg:         cfg,
 		OwnerClient: authv1.NewOwnerControllerClient(clientConn),
+		AppClient:   authv1.NewAppControllerClient(clientConn),
+		OrgClient:   authv1.NewOrganizationControllerClient(clientConn),
 	}
 }
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "github.com/viacheslavek/grpcauth/api/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/viacheslavek/grpcauth/auth/tests/suite"
+)
+
+func TestUpdateOwner_UpdateMask(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	got, err := s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "failed get owner")
+	id := got.GetId()
+
+	_, err = s.OwnerClient.UpdateOwner(ownerCtx, &authv1.UpdateOwnerRequest{
+		Id:          id,
+		DisplayName: "Jane Doe",
+		Email:       "ignored@example.com",
+		UpdateMask:  &fieldmaskpb.FieldMask{Paths: []string{"display_name"}},
+	})
+	require.NoError(t, err, "failed update display name")
+
+	got, err = s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Id: id})
+	require.NoError(t, err, "failed get owner")
+	assert.Equal(t, "Jane Doe", got.GetDisplayName(), "display name")
+	assert.Equal(t, owner.email, got.GetEmail(), "email is not in the mask")
+
+	_, err = s.OwnerClient.UpdateOwner(ownerCtx, &authv1.UpdateOwnerRequest{
+		Id: id, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name"}},
+	})
+	require.NoError(t, err, "failed clear display name")
+
+	got, err = s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Id: id})
+	require.NoError(t, err, "failed get owner")
+	assert.Empty(t, got.GetDisplayName(), "display name is cleared")
+	assert.Equal(t, owner.login, got.GetLogin(), "login")
+}
+
+func TestUpdateOwner_SelfPassword(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	got, err := s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "failed get owner")
+
+	_, err = s.OwnerClient.UpdateOwner(ownerCtx, &authv1.UpdateOwnerRequest{
+		Id: got.GetId(), Password: generateValidPassword(),
+	})
+	require.Error(t, err, "expected error when an owner sets its password without the current one")
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code(), "expected status code PermissionDenied")
+
+	_, err = s.OwnerClient.LoginOwner(s.Ctx, &authv1.LoginOwnerRequest{
+		Login: owner.login, Password: owner.password, AppId: app.GetId(),
+	})
+	require.NoError(t, err, "the password is not changed")
+}
+
+func TestUpdateOwner_Taken(t *testing.T) {
+	s := suite.New(t)
+
+	app := createAppAndCheckSuccess(s, t, generateAppName())
+	owner := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	other := loginNewOwnerAndCheckSuccess(s, t, app.GetId())
+	ownerCtx := withBearer(s.Ctx, owner.tokens.GetToken())
+
+	got, err := s.OwnerClient.GetOwner(ownerCtx, &authv1.GetOwnerRequest{Login: owner.login})
+	require.NoError(t, err, "failed get owner")
+
+	tests := []struct {
+		name string
+		req  *authv1.UpdateOwnerRequest
+	}{
+		{"login", &authv1.UpdateOwnerRequest{
+			Id: got.GetId(), Login: other.login, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"login"}},
+		}},
+		{"email", &authv1.UpdateOwnerRequest{
+			Id: got.GetId(), Email: other.email, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.OwnerClient.UpdateOwner(ownerCtx, tt.req)
+			require.Error(t, err, "expected error")
+			st, _ := status.FromError(err)
+			assert.Equal(t, codes.AlreadyExists, st.Code(), "expected status code AlreadyExists")
+		})
+	}
+}
+
+func TestUpdateOwner_InvalidMask(t *testing.T) {
+	s := suite.New(t)
+
+	admin := adminContext(s, t)
+
+	email, err := generateValidEmail(1000)
+	require.NoError(t, err, "email generate failed")
+	login := gofakeit.Username()
+	createOwnerAndCheckSuccess(s, t, login, email, generateValidPassword())
+
+	got, err := s.OwnerClient.GetOwner(admin, &authv1.GetOwnerRequest{Login: login})
+	require.NoError(t, err, "failed get owner")
+
+	tests := []struct {
+		name string
+		req  *authv1.UpdateOwnerRequest
+	}{
+		{"only id", &authv1.UpdateOwnerRequest{Id: got.GetId()}},
+		{"empty mask", &authv1.UpdateOwnerRequest{
+			Id: got.GetId(), Login: "ignored", UpdateMask: &fieldmaskpb.FieldMask{},
+		}},
+		{"clear email", &authv1.UpdateOwnerRequest{
+			Id: got.GetId(), UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+		}},
+		{"unknown path", &authv1.UpdateOwnerRequest{
+			Id: got.GetId(), UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.OwnerClient.UpdateOwner(admin, tt.req)
+			require.Error(t, err, "expected error")
+			st, _ := status.FromError(err)
+			assert.Equal(t, codes.InvalidArgument, st.Code(), "expected status code InvalidArgument")
+		})
+	}
+}